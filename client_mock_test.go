@@ -2,6 +2,7 @@ package servicefabric
 
 import (
 	"fmt"
+	"time"
 
 	sf "github.com/jjcollinge/servicefabric"
 )
@@ -12,36 +13,106 @@ type clientMock struct {
 	partitions                   *sf.PartitionItemsPage
 	replicas                     *sf.ReplicaItemsPage
 	instances                    *sf.InstanceItemsPage
+	applicationPages             map[string]*sf.ApplicationItemsPage
+	servicePages                 map[string]*sf.ServiceItemsPage
+	partitionPages               map[string]*sf.PartitionItemsPage
+	partitionNames               map[string]string
+	replicaPages                 map[string]*sf.ReplicaItemsPage
+	instancePages                map[string]*sf.InstanceItemsPage
+	applicationsError            error
+	servicesErrors               map[string]error
+	partitionsError              error
+	replicasError                error
+	instancesError               error
+	serviceExtensionMapError     error
 	getServicelabelsResult       map[string]string
 	expectedPropertyName         string
 	getServiceExtensionMapResult map[string]string
 	getPropertiesResult          map[string]string
+	properties                   map[string]map[string]string
+	events                       map[string][]fabricEvent
+	eventsError                  error
+	nodes                        *nodeItemsPage
+	nodesError                   error
+	upgradeProgress              map[string]*applicationUpgradeProgress
+	upgradeProgressError         error
 }
 
-func (c *clientMock) GetApplications() (*sf.ApplicationItemsPage, error) {
-	return c.applications, nil
+// The first page of each list is the plain field, following pages are
+// looked up in the matching *Pages map by continuation token.
+
+func (c *clientMock) GetApplications(continuationToken string) (*sf.ApplicationItemsPage, error) {
+	if c.applicationsError != nil {
+		return nil, c.applicationsError
+	}
+	if continuationToken == "" {
+		return c.applications, nil
+	}
+	if page, ok := c.applicationPages[continuationToken]; ok {
+		return page, nil
+	}
+	return nil, fmt.Errorf("unknown continuation token %s", continuationToken)
 }
 
-func (c *clientMock) GetServices(appName string) (*sf.ServiceItemsPage, error) {
-	return c.services, nil
+func (c *clientMock) GetServices(appName, continuationToken string) (*sf.ServiceItemsPage, error) {
+	if err, ok := c.servicesErrors[appName]; ok {
+		return nil, err
+	}
+	if continuationToken == "" {
+		return c.services, nil
+	}
+	if page, ok := c.servicePages[continuationToken]; ok {
+		return page, nil
+	}
+	return nil, fmt.Errorf("unknown continuation token %s", continuationToken)
 }
 
-func (c *clientMock) GetPartitions(appName, serviceName string) (*sf.PartitionItemsPage, error) {
-	return c.partitions, nil
+func (c *clientMock) GetPartitions(appName, serviceName, continuationToken string) (*partitionItemsPage, error) {
+	if c.partitionsError != nil {
+		return nil, c.partitionsError
+	}
+	if continuationToken == "" {
+		return &partitionItemsPage{PartitionItemsPage: *c.partitions, Names: c.partitionNames}, nil
+	}
+	if page, ok := c.partitionPages[continuationToken]; ok {
+		return &partitionItemsPage{PartitionItemsPage: *page, Names: c.partitionNames}, nil
+	}
+	return nil, fmt.Errorf("unknown continuation token %s", continuationToken)
 }
 
-func (c *clientMock) GetReplicas(appName, serviceName, partitionName string) (*sf.ReplicaItemsPage, error) {
-	return c.replicas, nil
+func (c *clientMock) GetReplicas(appName, serviceName, partitionName, continuationToken string) (*sf.ReplicaItemsPage, error) {
+	if c.replicasError != nil {
+		return nil, c.replicasError
+	}
+	if continuationToken == "" {
+		return c.replicas, nil
+	}
+	if page, ok := c.replicaPages[continuationToken]; ok {
+		return page, nil
+	}
+	return nil, fmt.Errorf("unknown continuation token %s", continuationToken)
 }
 
-func (c *clientMock) GetInstances(appName, serviceName, partitionName string) (*sf.InstanceItemsPage, error) {
-	return c.instances, nil
+func (c *clientMock) GetInstances(appName, serviceName, partitionName, continuationToken string) (*sf.InstanceItemsPage, error) {
+	if c.instancesError != nil {
+		return nil, c.instancesError
+	}
+	if continuationToken == "" {
+		return c.instances, nil
+	}
+	if page, ok := c.instancePages[continuationToken]; ok {
+		return page, nil
+	}
+	return nil, fmt.Errorf("unknown continuation token %s", continuationToken)
 }
 
 func (c *clientMock) GetServiceExtensionMap(service *sf.ServiceItem, app *sf.ApplicationItem, extensionKey string) (map[string]string, error) {
 	if extensionKey != traefikServiceFabricExtensionKey {
 		return nil, fmt.Errorf("extension key not expected value have: %s expect: %s", extensionKey, traefikServiceFabricExtensionKey)
 	}
+	if c.serviceExtensionMapError != nil {
+		return nil, c.serviceExtensionMapError
+	}
 	return c.getServiceExtensionMapResult, nil
 }
 
@@ -51,8 +122,38 @@ func (c *clientMock) GetServiceLabels(service *sf.ServiceItem, app *sf.Applicati
 
 // Note this is dumb mock the `exists`.
 func (c *clientMock) GetProperties(name string) (bool, map[string]string, error) {
+	if properties, ok := c.properties[name]; ok {
+		return true, properties, nil
+	}
 	if c.expectedPropertyName == name {
 		return true, c.getPropertiesResult, nil
 	}
 	return false, nil, nil
 }
+
+func (c *clientMock) GetEvents(entity string, start, end time.Time) ([]fabricEvent, error) {
+	if c.eventsError != nil {
+		return nil, c.eventsError
+	}
+	return c.events[entity], nil
+}
+
+func (c *clientMock) GetNodes(continuationToken string) (*nodeItemsPage, error) {
+	if c.nodesError != nil {
+		return nil, c.nodesError
+	}
+	if c.nodes == nil {
+		return &nodeItemsPage{}, nil
+	}
+	return c.nodes, nil
+}
+
+func (c *clientMock) GetUpgradeProgress(appName string) (*applicationUpgradeProgress, error) {
+	if c.upgradeProgressError != nil {
+		return nil, c.upgradeProgressError
+	}
+	if progress, ok := c.upgradeProgress[appName]; ok {
+		return progress, nil
+	}
+	return &applicationUpgradeProgress{}, nil
+}
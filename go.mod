@@ -12,7 +12,7 @@ require (
 	github.com/abronan/valkeyrie v0.0.0-20171113095143-063d875e3c5f // indirect
 	github.com/cenk/backoff v2.1.1+incompatible
 	github.com/containous/flaeg v1.4.1
-	github.com/containous/mux v0.0.0-20181024131434-c33f32e26898 // indirect
+	github.com/containous/mux v0.0.0-20181024131434-c33f32e26898
 	github.com/google/uuid v1.1.1 // indirect
 	github.com/gorilla/context v1.1.1 // indirect
 	github.com/huandu/xstrings v1.2.0 // indirect
@@ -20,7 +20,7 @@ require (
 	github.com/jjcollinge/logrus-appinsights v0.0.0-20180126100925-9b66602d496a
 	github.com/jjcollinge/servicefabric v0.0.2-0.20180125130438-8eebe170fa1b
 	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
-	github.com/mitchellh/hashstructure v1.0.0 // indirect
+	github.com/mitchellh/hashstructure v1.0.0
 	github.com/ogier/pflag v0.0.2-0.20160129220114-45c278ab3607 // indirect
 	github.com/patrickmn/go-cache v2.1.0+incompatible // indirect
 	github.com/ryanuber/go-glob v1.0.0 // indirect
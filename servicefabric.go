@@ -3,7 +3,9 @@ package servicefabric
 import (
 	"encoding/json"
 	"errors"
-	"net/http"
+	"fmt"
+	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,16 +32,28 @@ const (
 
 // Provider holds for configuration for the provider.
 type Provider struct {
-	provider.BaseProvider `mapstructure:",squash"`
-	ClusterManagementURL  string           `description:"Service Fabric API endpoint"`
-	APIVersion            string           `description:"Service Fabric API version" export:"true"`
-	RefreshSeconds        flaeg.Duration   `description:"Polling interval (in seconds)" export:"true"`
-	TLS                   *types.ClientTLS `description:"Enable TLS support" export:"true"`
-	AppInsightsClientName string           `description:"The client name, Identifies the cloud instance"`
-	AppInsightsKey        string           `description:"Application Insights Instrumentation Key"`
-	AppInsightsBatchSize  int              `description:"Number of trace lines per batch, optional"`
-	AppInsightsInterval   flaeg.Duration   `description:"The interval for sending data to Application Insights, optional"`
-	sfClient              sfClient
+	provider.BaseProvider  `mapstructure:",squash"`
+	ClusterManagementURL   string             `description:"Service Fabric API endpoints, comma separated"`
+	Clusters               []*Cluster         `description:"Named clusters to discover, replaces the top level connection settings" export:"true"`
+	APIVersion             string             `description:"Service Fabric API version" export:"true"`
+	RefreshSeconds         flaeg.Duration     `description:"Polling interval (in seconds)" export:"true"`
+	TLS                    *types.ClientTLS   `description:"Enable TLS support" export:"true"`
+	ServerCertificate      *ServerCertificate `description:"Pin the cluster server certificate by thumbprint or common name" export:"true"`
+	AAD                    *AADAuth           `description:"Enable Azure Active Directory authentication" export:"true"`
+	AppInsightsClientName  string             `description:"The client name, Identifies the cloud instance"`
+	AppInsightsKey         string             `description:"Application Insights Instrumentation Key"`
+	AppInsightsBatchSize   int                `description:"Number of trace lines per batch, optional"`
+	AppInsightsInterval    flaeg.Duration     `description:"The interval for sending data to Application Insights, optional"`
+	DiscoveryWorkers       int                `description:"Maximum number of concurrent Service Fabric API requests during discovery" export:"true"`
+	MaxStaleness           flaeg.Duration     `description:"Maximum time the last known services of a failing application are kept" export:"true"`
+	EventStore             *EventStore        `description:"Update the configuration from the EventStore events instead of polling" export:"true"`
+	PreferredEndpoints     []string           `description:"Endpoint names preferred when a replica exposes several HTTP endpoints" export:"true"`
+	HealthPolicy           *HealthPolicy      `description:"Replica statuses and health states routed to" export:"true"`
+	DisablingNodeWeight    int                `description:"Weight of the replicas on Disabling nodes, they are removed when not set" export:"true"`
+	DrainUpgradeDomains    bool               `description:"Remove the replicas in the upgrade domain being upgraded by an application upgrade" export:"true"`
+	LocalFaultDomain       string             `description:"Fault domain of the Traefik node, the replicas in it or in its children are preferred" export:"true"`
+	LocalFaultDomainWeight int                `description:"Multiplier of the weight of the replicas in the local fault domain, 10 by default" export:"true"`
+	clusters               []*clusterConnection
 }
 
 // Init the provider.
@@ -53,20 +67,32 @@ func (p *Provider) Init(constraints types.Constraints) error {
 		p.APIVersion = sf.DefaultAPIVersion
 	}
 
-	tlsConfig, err := p.TLS.CreateTLSConfig()
+	clusters, err := p.getClusters()
 	if err != nil {
 		return err
 	}
 
-	p.sfClient, err = sf.NewClient(http.DefaultClient, p.ClusterManagementURL, p.APIVersion, tlsConfig)
-	if err != nil {
-		return err
+	p.clusters = nil
+	for _, cluster := range clusters {
+		connection, err := newClusterConnection(cluster, p.APIVersion)
+		if err != nil {
+			return fmt.Errorf("%w%s", err, clusterLogSuffix(cluster.Name))
+		}
+		p.clusters = append(p.clusters, connection)
 	}
 
 	if p.RefreshSeconds <= 0 {
 		p.RefreshSeconds = flaeg.Duration(10 * time.Second)
 	}
 
+	if p.DiscoveryWorkers <= 0 {
+		p.DiscoveryWorkers = defaultDiscoveryWorkers
+	}
+
+	if p.MaxStaleness <= 0 {
+		p.MaxStaleness = flaeg.Duration(defaultMaxStaleness)
+	}
+
 	if p.AppInsightsClientName != "" && p.AppInsightsKey != "" {
 		if p.AppInsightsBatchSize == 0 {
 			p.AppInsightsBatchSize = 10
@@ -82,12 +108,28 @@ func (p *Provider) Init(constraints types.Constraints) error {
 // Provide allows the ServiceFabric provider to provide configurations to traefik
 // using the given configuration channel.
 func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool) error {
+	for _, cluster := range p.clusters {
+		watcher := cluster.certWatcher
+		if watcher == nil {
+			continue
+		}
+		pool.Go(func(stop chan bool) {
+			watcher.watch(stop, time.Duration(p.RefreshSeconds))
+		})
+	}
+
 	return p.updateConfig(configurationChan, pool, time.Duration(p.RefreshSeconds))
 }
 
 func (p *Provider) updateConfig(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, pollInterval time.Duration) error {
 	pool.Go(func(stop chan bool) {
+		tracker := &topologyTracker{}
+
 		operation := func() error {
+			if p.EventStore != nil {
+				return p.watchEvents(configurationChan, stop, tracker)
+			}
+
 			ticker := time.NewTicker(pollInterval)
 			for range ticker.C {
 				select {
@@ -100,14 +142,13 @@ func (p *Provider) updateConfig(configurationChan chan<- types.ConfigMessage, po
 					log.Info("Checking service fabric config")
 				}
 
-				configuration, err := p.getConfiguration()
+				services, err := p.getServices()
 				if err != nil {
 					return err
 				}
 
-				configurationChan <- types.ConfigMessage{
-					ProviderName:  "servicefabric",
-					Configuration: configuration,
+				if err = p.publish(configurationChan, tracker, services); err != nil {
+					return err
 				}
 			}
 			return nil
@@ -124,124 +165,462 @@ func (p *Provider) updateConfig(configurationChan chan<- types.ConfigMessage, po
 	return nil
 }
 
-func (p *Provider) getConfiguration() (*types.Configuration, error) {
-	services, err := getClusterServices(p.sfClient)
+// publish builds the configuration of services and sends it
+// unless it is the same as the previous one.
+func (p *Provider) publish(configurationChan chan<- types.ConfigMessage, tracker *topologyTracker, services []ServiceItemExtended) error {
+	configuration, err := p.buildConfiguration(services)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	changed, diff := tracker.update(services, configuration)
+	if !changed {
+		log.Debug("Service Fabric configuration unchanged, skipping update")
+		return nil
 	}
+	logTopologyDiff(diff)
 
-	return p.buildConfiguration(services)
+	configurationChan <- types.ConfigMessage{
+		ProviderName:  "servicefabric",
+		Configuration: configuration,
+	}
+	return nil
 }
 
-func getClusterServices(sfClient sfClient) ([]ServiceItemExtended, error) {
-	apps, err := sfClient.GetApplications()
+// getServices discovers the clusters in parallel. A cluster which cannot
+// be listed at all contributes the last known services of its applications,
+// the discovery only fails when no cluster could be listed.
+func (p *Provider) getServices() ([]ServiceItemExtended, error) {
+	clusterServices := make([][]ServiceItemExtended, len(p.clusters))
+	clusterErrors := make([]error, len(p.clusters))
+	forEach(len(p.clusters), func(i int) {
+		clusterServices[i], clusterErrors[i] = p.getClusterServices(p.clusters[i])
+	})
+
+	var services []ServiceItemExtended
+	var lastErr error
+	failed := 0
+	for i, cluster := range p.clusters {
+		err := clusterErrors[i]
+
+		var discoveryErr discoveryError
+		switch {
+		case err == nil:
+		case errors.As(err, &discoveryErr):
+			log.Errorf("Service Fabric discovery is incomplete%s: %v", clusterLogSuffix(cluster.name), err)
+		default:
+			failed++
+			lastErr = err
+			log.Errorf("Unable to discover Service Fabric services%s: %v", clusterLogSuffix(cluster.name), err)
+			if cluster.appCache != nil {
+				clusterServices[i] = cluster.appCache.lookupAll(time.Now(), p.getMaxStaleness())
+			}
+		}
+
+		services = append(services, clusterServices[i]...)
+	}
+
+	if failed > 0 && failed == len(p.clusters) && len(services) == 0 {
+		return nil, lastErr
+	}
+
+	if stale := countStaleServices(services); stale > 0 {
+		log.Warnf("Serving %d of %d Service Fabric services from their last known state", stale, len(services))
+	}
+	return services, nil
+}
+
+func (p *Provider) getClusterServices(cluster *clusterConnection) ([]ServiceItemExtended, error) {
+	apps, err := listApplications(cluster.client)
 	if err != nil {
 		return nil, err
 	}
 
-	var results []ServiceItemExtended
-	for _, app := range apps.Items {
-		services, err := sfClient.GetServices(app.ID)
-		if err != nil {
-			return nil, err
+	nodes, err := listNodes(cluster.client)
+	if err != nil {
+		log.Warnf("Unable to list Service Fabric nodes%s, node statuses are ignored: %v", clusterLogSuffix(cluster.name), err)
+	}
+	cluster.nodes = nodes
+
+	pool := newWorkerPool(p.DiscoveryWorkers)
+
+	appServices := make([][]ServiceItemExtended, len(apps))
+	appErrors := make([]error, len(apps))
+	forEach(len(apps), func(i int) {
+		appServices[i], appErrors[i] = p.getApplicationServices(cluster.client, pool, nodes, apps[i])
+		for j := range appServices[i] {
+			appServices[i][j].Cluster = cluster.name
 		}
+	})
 
-		for _, service := range services.Items {
-			item := ServiceItemExtended{
-				ServiceItem: service,
-				Application: app,
-			}
+	if cluster.appCache == nil {
+		cluster.appCache = newApplicationCache()
+	}
 
-			if labels, err := getLabels(sfClient, &service, &app); err != nil {
-				log.Error(err)
-			} else {
-				item.Labels = labels
-			}
+	maxStaleness := p.getMaxStaleness()
 
-			if partitions, err := sfClient.GetPartitions(app.ID, service.ID); err != nil {
-				log.Error(err)
-			} else {
-				for _, partition := range partitions.Items {
-					partitionExt := PartitionItemExtended{PartitionItem: partition}
-
-					switch {
-					case isStateful(item):
-						partitionExt.Replicas = getValidReplicas(sfClient, app, service, partition)
-					case isStateless(item):
-						partitionExt.Instances = getValidInstances(sfClient, app, service, partition)
-					default:
-						log.Errorf("Unsupported service kind %s in service %s", partition.ServiceKind, service.Name)
-						continue
-					}
+	now := time.Now()
+	appIDs := make(map[string]struct{}, len(apps))
 
-					item.Partitions = append(item.Partitions, partitionExt)
-				}
+	var results []ServiceItemExtended
+	errs := discoveryError{}
+	for i, app := range apps {
+		appIDs[app.ID] = struct{}{}
+
+		if appErrors[i] != nil {
+			errs[app.ID] = appErrors[i]
+			if cached, ok := cluster.appCache.lookup(app.ID, now, maxStaleness); ok {
+				results = append(results, cached...)
 			}
-
-			results = append(results, item)
+			continue
 		}
+
+		cluster.appCache.store(app.ID, appServices[i], now)
+		results = append(results, appServices[i]...)
 	}
+	cluster.appCache.retain(appIDs)
 
+	if len(errs) > 0 {
+		return results, errs
+	}
 	return results, nil
 }
 
-func getValidReplicas(sfClient sfClient, app sf.ApplicationItem, service sf.ServiceItem, partition sf.PartitionItem) []sf.ReplicaItem {
-	var validReplicas []sf.ReplicaItem
+func (p *Provider) getMaxStaleness() time.Duration {
+	if p.MaxStaleness <= 0 {
+		return defaultMaxStaleness
+	}
+	return time.Duration(p.MaxStaleness)
+}
 
-	if replicas, err := sfClient.GetReplicas(app.ID, service.ID, partition.PartitionInformation.ID); err != nil {
-		log.Error(err)
-	} else {
-		for _, instance := range replicas.Items {
-			if isHealthy(instance.ReplicaItemBase) && hasHTTPEndpoint(instance.ReplicaItemBase) {
-				validReplicas = append(validReplicas, instance)
-			}
+func (p *Provider) getApplicationServices(sfClient sfClient, pool *workerPool, nodes map[string]NodeItem, app sf.ApplicationItem) ([]ServiceItemExtended, error) {
+	var services []sf.ServiceItem
+	var err error
+	pool.run(func() {
+		services, err = listServices(sfClient, app.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	filter := p.getNodeFilter(sfClient, pool, nodes, app)
+
+	var appLabels applicationLabels
+	pool.run(func() {
+		appLabels = getApplicationLabels(sfClient, &app)
+	})
+
+	items := make([]ServiceItemExtended, len(services))
+	itemErrors := make([]error, len(services))
+	forEach(len(services), func(i int) {
+		items[i], itemErrors[i] = p.getServiceItem(sfClient, pool, filter, appLabels, app, services[i])
+	})
+	if err := joinErrors(itemErrors); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// getServiceItem discovers the partitions and replicas of the service.
+// The errors of the partitions are collected, a service missing some
+// of its partitions or labels is not returned as discovered.
+func (p *Provider) getServiceItem(sfClient sfClient, pool *workerPool, filter nodeFilter, appLabels applicationLabels, app sf.ApplicationItem, service sf.ServiceItem) (ServiceItemExtended, error) {
+	item := ServiceItemExtended{
+		ServiceItem: service,
+		Application: app,
+	}
+
+	var err error
+	pool.run(func() {
+		item.Labels, err = getLabels(sfClient, &service, &app, appLabels)
+	})
+	if err != nil {
+		return item, fmt.Errorf("service %s: %w", service.Name, err)
+	}
+
+	var partitions []PartitionItemExtended
+	pool.run(func() {
+		partitions, err = listPartitions(sfClient, app.ID, service.ID)
+	})
+	if err != nil {
+		return item, fmt.Errorf("service %s: %w", service.Name, err)
+	}
+
+	policy := p.getHealthPolicy(item.Labels)
+
+	partitionItems := make([]*PartitionItemExtended, len(partitions))
+	partitionErrors := make([]error, len(partitions))
+	forEach(len(partitions), func(i int) {
+		partitionExt := &partitions[i]
+		partition := partitionExt.PartitionItem
+
+		var err error
+		switch {
+		case isStateful(item):
+			pool.run(func() {
+				partitionExt.Replicas, err = p.getValidReplicas(sfClient, policy, filter, app, service, partition)
+			})
+		case isStateless(item):
+			pool.run(func() {
+				partitionExt.Instances, err = p.getValidInstances(sfClient, policy, filter, app, service, partition)
+			})
+		default:
+			log.Errorf("Unsupported service kind %s in service %s", partition.ServiceKind, service.Name)
+			return
+		}
+		if err != nil {
+			partitionErrors[i] = fmt.Errorf("service %s partition %s: %w", service.Name, partition.PartitionInformation.ID, err)
+			return
+		}
+
+		partitionExt.Nodes = getPartitionNodes(filter.nodes, *partitionExt)
+		partitionItems[i] = partitionExt
+	})
+	if err := joinErrors(partitionErrors); err != nil {
+		return item, err
+	}
+
+	for _, partitionExt := range partitionItems {
+		if partitionExt != nil {
+			item.Partitions = append(item.Partitions, *partitionExt)
+		}
+	}
+	return item, nil
+}
+
+func (p *Provider) getValidReplicas(sfClient sfClient, policy *HealthPolicy, filter nodeFilter, app sf.ApplicationItem, service sf.ServiceItem, partition sf.PartitionItem) ([]sf.ReplicaItem, error) {
+	replicas, err := listReplicas(sfClient, app.ID, service.ID, partition.PartitionInformation.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var validReplicas []sf.ReplicaItem
+	for _, instance := range replicas {
+		if err := p.checkReplica(instance.ReplicaItemBase, policy, filter); err != nil {
+			log.Debugf("Skipping replica %s of partition %s in service %s: %v", instance.ID, partition.PartitionInformation.ID, service.Name, err)
+			continue
 		}
+		validReplicas = append(validReplicas, instance)
 	}
-	return validReplicas
+	return validReplicas, nil
 }
 
-func getValidInstances(sfClient sfClient, app sf.ApplicationItem, service sf.ServiceItem, partition sf.PartitionItem) []sf.InstanceItem {
+func (p *Provider) getValidInstances(sfClient sfClient, policy *HealthPolicy, filter nodeFilter, app sf.ApplicationItem, service sf.ServiceItem, partition sf.PartitionItem) ([]sf.InstanceItem, error) {
+	instances, err := listInstances(sfClient, app.ID, service.ID, partition.PartitionInformation.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	var validInstances []sf.InstanceItem
+	for _, instance := range instances {
+		if err := p.checkReplica(instance.ReplicaItemBase, policy, filter); err != nil {
+			log.Debugf("Skipping instance %s of partition %s in service %s: %v", instance.ID, partition.PartitionInformation.ID, service.Name, err)
+			continue
+		}
+		validInstances = append(validInstances, instance)
+	}
+	return validInstances, nil
+}
 
-	if instances, err := sfClient.GetInstances(app.ID, service.ID, partition.PartitionInformation.ID); err != nil {
-		log.Error(err)
-	} else {
-		for _, instance := range instances.Items {
-			if isHealthy(instance.ReplicaItemBase) && hasHTTPEndpoint(instance.ReplicaItemBase) {
-				validInstances = append(validInstances, instance)
-			}
+// listApplications follows the continuation tokens until every page
+// of applications has been read.
+func listApplications(sfClient sfClient) ([]sf.ApplicationItem, error) {
+	var items []sf.ApplicationItem
+	var token string
+	for {
+		page, err := sfClient.GetApplications(token)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+
+		token, err = nextContinuationToken(token, page.ContinuationToken)
+		if err != nil {
+			return nil, err
+		}
+		if token == "" {
+			return items, nil
 		}
 	}
-	return validInstances
 }
 
-func isHealthy(instanceData *sf.ReplicaItemBase) bool {
-	return instanceData != nil && (instanceData.ReplicaStatus == "Ready" && instanceData.HealthState != "Error")
+func listServices(sfClient sfClient, appName string) ([]sf.ServiceItem, error) {
+	var items []sf.ServiceItem
+	var token string
+	for {
+		page, err := sfClient.GetServices(appName, token)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+
+		token, err = nextContinuationToken(token, page.ContinuationToken)
+		if err != nil {
+			return nil, err
+		}
+		if token == "" {
+			return items, nil
+		}
+	}
 }
 
-func hasHTTPEndpoint(instanceData *sf.ReplicaItemBase) bool {
-	_, err := getReplicaDefaultEndpoint(instanceData)
-	return err == nil
+func listPartitions(sfClient sfClient, appName, serviceName string) ([]PartitionItemExtended, error) {
+	var items []PartitionItemExtended
+	var token string
+	for {
+		page, err := sfClient.GetPartitions(appName, serviceName, token)
+		if err != nil {
+			return nil, err
+		}
+		for _, partition := range page.Items {
+			items = append(items, PartitionItemExtended{
+				PartitionItem: partition,
+				Name:          page.Names[partition.PartitionInformation.ID],
+			})
+		}
+
+		token, err = nextContinuationToken(token, page.ContinuationToken)
+		if err != nil {
+			return nil, err
+		}
+		if token == "" {
+			return items, nil
+		}
+	}
+}
+
+func listReplicas(sfClient sfClient, appName, serviceName, partitionName string) ([]sf.ReplicaItem, error) {
+	var items []sf.ReplicaItem
+	var token string
+	for {
+		page, err := sfClient.GetReplicas(appName, serviceName, partitionName, token)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+
+		token, err = nextContinuationToken(token, page.ContinuationToken)
+		if err != nil {
+			return nil, err
+		}
+		if token == "" {
+			return items, nil
+		}
+	}
 }
 
-func getReplicaDefaultEndpoint(replicaData *sf.ReplicaItemBase) (string, error) {
+func listInstances(sfClient sfClient, appName, serviceName, partitionName string) ([]sf.InstanceItem, error) {
+	var items []sf.InstanceItem
+	var token string
+	for {
+		page, err := sfClient.GetInstances(appName, serviceName, partitionName, token)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+
+		token, err = nextContinuationToken(token, page.ContinuationToken)
+		if err != nil {
+			return nil, err
+		}
+		if token == "" {
+			return items, nil
+		}
+	}
+}
+
+// nextContinuationToken returns the token of the next page to request,
+// an empty token means the last page has been read.
+// A token equal to the current one would make the walk loop forever.
+func nextContinuationToken(current string, next *string) (string, error) {
+	if next == nil || *next == "" {
+		return "", nil
+	}
+	if *next == current {
+		return "", fmt.Errorf("continuation token %q returned for its own page", current)
+	}
+	return *next, nil
+}
+
+// checkReplica returns why a replica cannot be routed to.
+func (p *Provider) checkReplica(instanceData *sf.ReplicaItemBase, policy *HealthPolicy, filter nodeFilter) error {
+	if instanceData == nil {
+		return errors.New("missing replica data")
+	}
+	if !isHealthy(instanceData, policy) {
+		return fmt.Errorf("replica status %s with health state %s", instanceData.ReplicaStatus, instanceData.HealthState)
+	}
+	if _, err := getReplicaDefaultEndpoint(instanceData, nil); err != nil {
+		return err
+	}
+	return p.checkNode(instanceData, filter)
+}
+
+// getReplicaDefaultEndpoint returns the HTTP endpoint of the replica:
+// the first of the preferred endpoint names, otherwise the HTTPS endpoints
+// come before the HTTP ones, in endpoint name order.
+func getReplicaDefaultEndpoint(replicaData *sf.ReplicaItemBase, preferredNames []string) (string, error) {
 	endpoints, err := decodeEndpointData(replicaData.Address)
 	if err != nil {
 		return "", err
 	}
 
-	var defaultHTTPEndpoint string
-	for _, v := range endpoints {
-		if strings.Contains(v, "http") {
-			defaultHTTPEndpoint = v
-			break
+	names := make([]string, 0, len(endpoints))
+	for name := range endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	httpEndpoints := make(map[string]*url.URL)
+	var reasons []string
+	for _, name := range names {
+		endpoint, err := parseHTTPEndpoint(endpoints[name])
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("endpoint %q: %v", name, err))
+			continue
+		}
+		httpEndpoints[name] = endpoint
+	}
+
+	if len(httpEndpoints) == 0 {
+		if len(reasons) == 0 {
+			return "", errors.New("no endpoint found")
+		}
+		return "", fmt.Errorf("no HTTP endpoint found: %s", strings.Join(reasons, ", "))
+	}
+
+	for _, name := range preferredNames {
+		if _, exists := httpEndpoints[name]; exists {
+			return endpoints[name], nil
+		}
+	}
+
+	for _, scheme := range []string{"https", "http"} {
+		for _, name := range names {
+			if endpoint, exists := httpEndpoints[name]; exists && endpoint.Scheme == scheme {
+				return endpoints[name], nil
+			}
 		}
 	}
+	return "", errors.New("no HTTP endpoint found")
+}
+
+// parseHTTPEndpoint parses an endpoint address, only the absolute
+// http and https URLs are accepted.
+func parseHTTPEndpoint(address string) (*url.URL, error) {
+	endpoint, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(defaultHTTPEndpoint) == 0 {
-		return "", errors.New("no default endpoint found")
+	endpoint.Scheme = strings.ToLower(endpoint.Scheme)
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q", endpoint.Scheme)
+	}
+	if endpoint.Host == "" {
+		return nil, errors.New("missing host")
 	}
-	return defaultHTTPEndpoint, nil
+	return endpoint, nil
 }
 
 func decodeEndpointData(endpointData string) (map[string]string, error) {
@@ -272,16 +651,58 @@ func isStateless(service ServiceItemExtended) bool {
 	return service.ServiceKind == kindStateless
 }
 
-// Return a set of labels from the Extension and Property manager
-// Allow Extension labels to disable importing labels from the property manager.
-func getLabels(sfClient sfClient, service *sf.ServiceItem, app *sf.ApplicationItem) (map[string]string, error) {
-	labels, err := sfClient.GetServiceExtensionMap(service, app, traefikServiceFabricExtensionKey)
+// applicationLabels holds the labels every service of an application inherits.
+type applicationLabels struct {
+	parameters map[string]string
+	properties map[string]string
+}
+
+// getApplicationLabels returns the application parameters named after
+// a label, e.g. traefik.frontend.entryPoints, and the properties of the
+// application name, unless the parameters disable the label overrides.
+func getApplicationLabels(sfClient sfClient, app *sf.ApplicationItem) applicationLabels {
+	appLabels := applicationLabels{parameters: make(map[string]string)}
+	for _, param := range app.Parameters {
+		if param != nil && strings.HasPrefix(param.Key, label.Prefix) {
+			appLabels.parameters[param.Key] = getApplicationParameter(*app, param.Key)
+		}
+	}
+
+	if label.GetBoolValue(appLabels.parameters, traefikSFEnableLabelOverrides, traefikSFEnableLabelOverridesDefault) {
+		if exists, properties, err := sfClient.GetProperties(app.ID); err == nil && exists {
+			appLabels.properties = properties
+		}
+	}
+	return appLabels
+}
+
+// getLabels returns the labels of the service, read from the service
+// manifest extension and the properties of the service name.
+// The service inherits the labels of its application, the service labels
+// take precedence over them.
+// Extension labels can disable importing labels from the property manager.
+func getLabels(sfClient sfClient, service *sf.ServiceItem, app *sf.ApplicationItem, appLabels applicationLabels) (map[string]string, error) {
+	extensionLabels, err := sfClient.GetServiceExtensionMap(service, app, traefikServiceFabricExtensionKey)
 	if err != nil {
 		log.Errorf("Error retrieving serviceExtensionMap: %v", err)
 		return nil, err
 	}
 
+	labels := make(map[string]string, len(appLabels.parameters)+len(extensionLabels))
+	for key, value := range appLabels.parameters {
+		labels[key] = value
+	}
+	for key, value := range extensionLabels {
+		labels[key] = value
+	}
+
 	if label.GetBoolValue(labels, traefikSFEnableLabelOverrides, traefikSFEnableLabelOverridesDefault) {
+		for key, value := range appLabels.properties {
+			if _, isServiceLabel := extensionLabels[key]; !isServiceLabel {
+				labels[key] = value
+			}
+		}
+
 		if exists, properties, err := sfClient.GetProperties(service.ID); err == nil && exists {
 			for key, value := range properties {
 				labels[key] = value
@@ -0,0 +1,209 @@
+package servicefabric
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/traefik/traefik/log"
+)
+
+const (
+	aadAuthorityURL          = "https://login.microsoftonline.com/"
+	aadManagedIdentityURL    = "http://169.254.169.254/metadata/identity/oauth2/token"
+	aadManagedIdentityAPI    = "2018-02-01"
+	aadTokenRefreshMargin    = 5 * time.Minute
+	aadTokenRequestTimeout   = 30 * time.Second
+	aadDefaultTokenExpiresIn = time.Hour
+)
+
+// AADAuth holds the Azure Active Directory settings used to authenticate
+// against an AAD-secured cluster management endpoint.
+type AADAuth struct {
+	TenantID        string `description:"Azure Active Directory tenant ID"`
+	ClientID        string `description:"Client ID of the application, or of the user-assigned managed identity"`
+	ClientSecret    string `description:"Client secret of the application"`
+	Resource        string `description:"Application ID of the cluster the token is requested for"`
+	ManagedIdentity bool   `description:"Acquire tokens with the managed identity of the host" export:"true"`
+	TokenEndpoint   string `description:"Override the token endpoint, optional"`
+}
+
+func (a *AADAuth) validate() error {
+	if a.Resource == "" {
+		return errors.New("AAD resource must be set")
+	}
+
+	if a.ManagedIdentity {
+		return nil
+	}
+
+	if a.ClientID == "" || a.ClientSecret == "" {
+		return errors.New("AAD client ID and client secret must be set when managed identity is not used")
+	}
+	if a.TenantID == "" && a.TokenEndpoint == "" {
+		return errors.New("AAD tenant ID or token endpoint must be set")
+	}
+	return nil
+}
+
+// aadTokenSource acquires access tokens and caches them until
+// they are about to expire.
+type aadTokenSource struct {
+	config     AADAuth
+	httpClient *http.Client
+
+	lock      sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func newAADTokenSource(config AADAuth) (*aadTokenSource, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return &aadTokenSource{
+		config:     config,
+		httpClient: &http.Client{Timeout: aadTokenRequestTimeout},
+	}, nil
+}
+
+// Token returns a valid access token, requesting a new one
+// when the cached token expires within aadTokenRefreshMargin.
+func (s *aadTokenSource) Token() (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.token != "" && time.Now().Add(aadTokenRefreshMargin).Before(s.expiresAt) {
+		return s.token, nil
+	}
+
+	var req *http.Request
+	var err error
+	if s.config.ManagedIdentity {
+		req, err = s.managedIdentityRequest()
+	} else {
+		req, err = s.clientCredentialsRequest()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	token, expiresIn, err := s.requestToken(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to acquire AAD token: %w", err)
+	}
+
+	log.Debugf("Acquired AAD token for resource %s, expires in %s", s.config.Resource, expiresIn)
+
+	s.token = token
+	s.expiresAt = time.Now().Add(expiresIn)
+	return s.token, nil
+}
+
+// invalidate drops the cached token so the next call requests a new one.
+func (s *aadTokenSource) invalidate() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.token = ""
+}
+
+func (s *aadTokenSource) clientCredentialsRequest() (*http.Request, error) {
+	endpoint := s.config.TokenEndpoint
+	if endpoint == "" {
+		endpoint = aadAuthorityURL + url.PathEscape(s.config.TenantID) + "/oauth2/token"
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", s.config.ClientID)
+	form.Set("client_secret", s.config.ClientSecret)
+	form.Set("resource", s.config.Resource)
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+func (s *aadTokenSource) managedIdentityRequest() (*http.Request, error) {
+	endpoint := s.config.TokenEndpoint
+	if endpoint == "" {
+		endpoint = aadManagedIdentityURL
+	}
+
+	params := url.Values{}
+	params.Set("api-version", aadManagedIdentityAPI)
+	params.Set("resource", s.config.Resource)
+	if s.config.ClientID != "" {
+		params.Set("client_id", s.config.ClientID)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	return req, nil
+}
+
+func (s *aadTokenSource) requestToken(req *http.Request) (string, time.Duration, error) {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token endpoint %s responded with status %s", req.URL.Host, resp.Status)
+	}
+
+	// expires_in is a string for the v1 and managed identity endpoints.
+	var tokenResponse struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return "", 0, fmt.Errorf("could not deserialize token response: %w", err)
+	}
+
+	if tokenResponse.AccessToken == "" {
+		return "", 0, errors.New("token response without access token")
+	}
+
+	expiresIn := aadDefaultTokenExpiresIn
+	if seconds, parseErr := tokenResponse.ExpiresIn.Int64(); parseErr == nil && seconds > 0 {
+		expiresIn = time.Duration(seconds) * time.Second
+	}
+	return tokenResponse.AccessToken, expiresIn, nil
+}
+
+// bearerTransport attaches an AAD access token to every request.
+type bearerTransport struct {
+	base   http.RoundTripper
+	tokens *aadTokenSource
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.tokens.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	authReq := req.Clone(req.Context())
+	authReq.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := t.base.RoundTrip(authReq)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		t.tokens.invalidate()
+	}
+	return resp, err
+}
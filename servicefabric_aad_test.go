@@ -0,0 +1,194 @@
+package servicefabric
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAADTokenSourceClientCredentials(t *testing.T) {
+	var requests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.NoError(t, req.ParseForm())
+		assert.Equal(t, "client_credentials", req.PostForm.Get("grant_type"))
+		assert.Equal(t, "client", req.PostForm.Get("client_id"))
+		assert.Equal(t, "secret", req.PostForm.Get("client_secret"))
+		assert.Equal(t, "cluster-app", req.PostForm.Get("resource"))
+
+		fmt.Fprint(rw, `{"token_type":"Bearer","expires_in":"3599","access_token":"token-1"}`)
+	}))
+	defer tokenServer.Close()
+
+	tokens, err := newAADTokenSource(AADAuth{
+		ClientID:      "client",
+		ClientSecret:  "secret",
+		Resource:      "cluster-app",
+		TokenEndpoint: tokenServer.URL,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		token, tokenErr := tokens.Token()
+		require.NoError(t, tokenErr)
+		assert.Equal(t, "token-1", token)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&requests), "token should be cached")
+}
+
+func TestAADTokenSourceRefreshesExpiringToken(t *testing.T) {
+	var requests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		count := atomic.AddInt32(&requests, 1)
+		// The token expires within the refresh margin.
+		fmt.Fprintf(rw, `{"expires_in":60,"access_token":"token-%d"}`, count)
+	}))
+	defer tokenServer.Close()
+
+	tokens, err := newAADTokenSource(AADAuth{
+		ClientID:      "client",
+		ClientSecret:  "secret",
+		Resource:      "cluster-app",
+		TokenEndpoint: tokenServer.URL,
+	})
+	require.NoError(t, err)
+
+	token, err := tokens.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	token, err = tokens.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+}
+
+func TestAADTokenSourceManagedIdentity(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Metadata") != "true" {
+			http.Error(rw, "missing metadata header", http.StatusBadRequest)
+			return
+		}
+
+		assert.Equal(t, http.MethodGet, req.Method)
+		assert.Equal(t, "cluster-app", req.URL.Query().Get("resource"))
+		assert.Equal(t, "identity", req.URL.Query().Get("client_id"))
+
+		fmt.Fprint(rw, `{"expires_in":"3599","access_token":"msi-token"}`)
+	}))
+	defer tokenServer.Close()
+
+	tokens, err := newAADTokenSource(AADAuth{
+		ClientID:        "identity",
+		Resource:        "cluster-app",
+		ManagedIdentity: true,
+		TokenEndpoint:   tokenServer.URL,
+	})
+	require.NoError(t, err)
+
+	token, err := tokens.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "msi-token", token)
+}
+
+func TestAADTokenSourceErrorStatus(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, "invalid_client", http.StatusUnauthorized)
+	}))
+	defer tokenServer.Close()
+
+	tokens, err := newAADTokenSource(AADAuth{
+		ClientID:      "client",
+		ClientSecret:  "secret",
+		Resource:      "cluster-app",
+		TokenEndpoint: tokenServer.URL,
+	})
+	require.NoError(t, err)
+
+	_, err = tokens.Token()
+	require.Error(t, err)
+}
+
+func TestAADAuthValidate(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        AADAuth
+		errorExpected bool
+	}{
+		{
+			desc:   "client credentials",
+			config: AADAuth{TenantID: "tenant", ClientID: "client", ClientSecret: "secret", Resource: "app"},
+		},
+		{
+			desc:   "managed identity",
+			config: AADAuth{ManagedIdentity: true, Resource: "app"},
+		},
+		{
+			desc:          "missing resource",
+			config:        AADAuth{ManagedIdentity: true},
+			errorExpected: true,
+		},
+		{
+			desc:          "missing secret",
+			config:        AADAuth{TenantID: "tenant", ClientID: "client", Resource: "app"},
+			errorExpected: true,
+		},
+		{
+			desc:          "missing tenant",
+			config:        AADAuth{ClientID: "client", ClientSecret: "secret", Resource: "app"},
+			errorExpected: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.validate()
+			if test.errorExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClusterClientSendsBearerToken(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"expires_in":"3599","access_token":"cluster-token"}`)
+	}))
+	defer tokenServer.Close()
+
+	clusterServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer cluster-token" {
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(rw, `{"Items":[{"Id":"app1"}]}`)
+	}))
+	defer clusterServer.Close()
+
+	httpClient, err := newHTTPClient(newTLSTransport(nil), &AADAuth{
+		ClientID:      "client",
+		ClientSecret:  "secret",
+		Resource:      "cluster-app",
+		TokenEndpoint: tokenServer.URL,
+	})
+	require.NoError(t, err)
+
+	client, err := newClusterClient(httpClient, clusterServer.URL, "")
+	require.NoError(t, err)
+
+	apps, err := listApplications(client)
+	require.NoError(t, err)
+	require.Len(t, apps, 1)
+	assert.Equal(t, "app1", apps[0].ID)
+}
@@ -0,0 +1,106 @@
+package servicefabric
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/traefik/traefik/log"
+)
+
+const defaultMaxStaleness = 5 * time.Minute
+
+// applicationCache keeps the last successful discovery result of each
+// application so a failing application does not drop its routes at once.
+type applicationCache struct {
+	lock  sync.Mutex
+	items map[string]cachedApplication
+}
+
+type cachedApplication struct {
+	services     []ServiceItemExtended
+	discoveredAt time.Time
+}
+
+func newApplicationCache() *applicationCache {
+	return &applicationCache{items: make(map[string]cachedApplication)}
+}
+
+func (c *applicationCache) store(appID string, services []ServiceItemExtended, now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.items[appID] = cachedApplication{services: services, discoveredAt: now}
+}
+
+// lookup returns the cached services of the application marked as stale.
+// Entries older than maxStaleness are dropped.
+func (c *applicationCache) lookup(appID string, now time.Time, maxStaleness time.Duration) ([]ServiceItemExtended, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	cached, exists := c.items[appID]
+	if !exists {
+		return nil, false
+	}
+
+	age := now.Sub(cached.discoveredAt)
+	if age > maxStaleness {
+		log.Errorf("Dropping services of application %s, last discovered %s ago", appID, age)
+		delete(c.items, appID)
+		return nil, false
+	}
+
+	log.Warnf("Using last known services of application %s, discovered %s ago", appID, age)
+
+	services := make([]ServiceItemExtended, len(cached.services))
+	for i, service := range cached.services {
+		service.Stale = true
+		services[i] = service
+	}
+	return services, true
+}
+
+// lookupAll returns the cached services of every application,
+// used when the applications of the cluster cannot be listed.
+func (c *applicationCache) lookupAll(now time.Time, maxStaleness time.Duration) []ServiceItemExtended {
+	c.lock.Lock()
+	appIDs := make([]string, 0, len(c.items))
+	for appID := range c.items {
+		appIDs = append(appIDs, appID)
+	}
+	c.lock.Unlock()
+	sort.Strings(appIDs)
+
+	var services []ServiceItemExtended
+	for _, appID := range appIDs {
+		if cached, ok := c.lookup(appID, now, maxStaleness); ok {
+			services = append(services, cached...)
+		}
+	}
+	return services
+}
+
+// retain drops the applications which are no longer in the cluster.
+func (c *applicationCache) retain(appIDs map[string]struct{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for appID := range c.items {
+		if _, exists := appIDs[appID]; !exists {
+			delete(c.items, appID)
+		}
+	}
+}
+
+// countStaleServices returns the number of services
+// coming from the last known state of their application.
+func countStaleServices(services []ServiceItemExtended) int {
+	stale := 0
+	for _, service := range services {
+		if service.Stale {
+			stale++
+		}
+	}
+	return stale
+}
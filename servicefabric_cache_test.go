@@ -0,0 +1,154 @@
+package servicefabric
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	sf "github.com/jjcollinge/servicefabric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/provider/label"
+)
+
+func TestGetClusterServicesKeepsLastKnownGoodApplication(t *testing.T) {
+	client := &clientMock{
+		applications: apps,
+		services:     services,
+		partitions:   partitions,
+		instances:    instances,
+		getServiceExtensionMapResult: map[string]string{
+			label.TraefikEnable: "true",
+		},
+	}
+
+	provider := Provider{MaxStaleness: flaeg.Duration(time.Hour)}
+	cluster := &clusterConnection{client: client}
+
+	serviceItems, err := provider.getClusterServices(cluster)
+	require.NoError(t, err)
+	require.Len(t, serviceItems, 1)
+	assert.False(t, serviceItems[0].Stale)
+
+	client.servicesErrors = map[string]error{
+		apps.Items[0].ID: errors.New("services unavailable"),
+	}
+
+	staleItems, err := provider.getClusterServices(cluster)
+	require.Error(t, err)
+	require.Len(t, staleItems, 1)
+	assert.True(t, staleItems[0].Stale)
+	assert.Equal(t, serviceItems[0].Partitions, staleItems[0].Partitions)
+
+	client.servicesErrors = nil
+
+	freshItems, err := provider.getClusterServices(cluster)
+	require.NoError(t, err)
+	require.Len(t, freshItems, 1)
+	assert.False(t, freshItems[0].Stale)
+}
+
+func TestGetClusterServicesKeepsApplicationOnServiceErrors(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		breakClient func(client *clientMock)
+	}{
+		{
+			desc: "labels unavailable",
+			breakClient: func(client *clientMock) {
+				client.serviceExtensionMapError = errors.New("labels unavailable")
+			},
+		},
+		{
+			desc: "partitions unavailable",
+			breakClient: func(client *clientMock) {
+				client.partitionsError = errors.New("partitions unavailable")
+			},
+		},
+		{
+			desc: "instances unavailable",
+			breakClient: func(client *clientMock) {
+				client.instancesError = errors.New("instances unavailable")
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := &clientMock{
+				applications: apps,
+				services:     services,
+				partitions:   partitions,
+				instances:    instances,
+				getServiceExtensionMapResult: map[string]string{
+					label.TraefikEnable: "true",
+				},
+			}
+
+			provider := Provider{MaxStaleness: flaeg.Duration(time.Hour)}
+			cluster := &clusterConnection{client: client}
+
+			serviceItems, err := provider.getClusterServices(cluster)
+			require.NoError(t, err)
+			require.Len(t, serviceItems, 1)
+
+			test.breakClient(client)
+
+			staleItems, err := provider.getClusterServices(cluster)
+			require.Error(t, err)
+			require.Len(t, staleItems, 1)
+			assert.True(t, staleItems[0].Stale)
+			assert.Equal(t, serviceItems[0].Labels, staleItems[0].Labels)
+			assert.Equal(t, serviceItems[0].Partitions, staleItems[0].Partitions)
+		})
+	}
+}
+
+func TestGetClusterServicesDropsExpiredApplication(t *testing.T) {
+	client := &clientMock{
+		applications: apps,
+		services:     services,
+		partitions:   partitions,
+		instances:    instances,
+		getServiceExtensionMapResult: map[string]string{
+			label.TraefikEnable: "true",
+		},
+	}
+
+	provider := Provider{MaxStaleness: flaeg.Duration(time.Millisecond)}
+	cluster := &clusterConnection{client: client}
+
+	_, err := provider.getClusterServices(cluster)
+	require.NoError(t, err)
+
+	client.servicesErrors = map[string]error{
+		apps.Items[0].ID: errors.New("services unavailable"),
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	serviceItems, err := provider.getClusterServices(cluster)
+	require.Error(t, err)
+	assert.Empty(t, serviceItems)
+}
+
+func TestApplicationCacheRetain(t *testing.T) {
+	cache := newApplicationCache()
+	now := time.Now()
+
+	cache.store("App1", []ServiceItemExtended{{ServiceItem: sf.ServiceItem{Name: "fabric:/App1/Svc"}}}, now)
+	cache.store("App2", []ServiceItemExtended{{ServiceItem: sf.ServiceItem{Name: "fabric:/App2/Svc"}}}, now)
+
+	cache.retain(map[string]struct{}{"App2": {}})
+
+	_, exists := cache.lookup("App1", now, time.Hour)
+	assert.False(t, exists)
+
+	services, exists := cache.lookup("App2", now, time.Hour)
+	require.True(t, exists)
+	require.Len(t, services, 1)
+	assert.True(t, services[0].Stale)
+}
@@ -0,0 +1,219 @@
+package servicefabric
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	sf "github.com/jjcollinge/servicefabric"
+)
+
+var _ sfClient = (*clusterClient)(nil)
+
+// clusterClient implements sfClient against the Service Fabric REST API.
+// Label lookups are delegated to the upstream client while the list calls
+// are issued directly, so each call returns exactly one page and hands the
+// ContinuationToken back to the caller.
+type clusterClient struct {
+	client     *sf.Client
+	httpClient *http.Client
+	endpoint   string
+	apiVersion string
+}
+
+// newHTTPClient builds the HTTP client used for every request to the cluster,
+// on top of the base transport.
+func newHTTPClient(base http.RoundTripper, aad *AADAuth) (*http.Client, error) {
+	roundTripper := base
+	if aad != nil {
+		tokens, err := newAADTokenSource(*aad)
+		if err != nil {
+			return nil, err
+		}
+		roundTripper = &bearerTransport{base: roundTripper, tokens: tokens}
+	}
+
+	return &http.Client{Transport: roundTripper}, nil
+}
+
+func newTLSTransport(tlsConfig *tls.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		tlsConfig.Renegotiation = tls.RenegotiateFreelyAsClient
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport
+}
+
+// reloadableTransport sends requests through a TLS transport
+// which can be replaced while the provider is running.
+type reloadableTransport struct {
+	lock      sync.RWMutex
+	transport *http.Transport
+}
+
+func newReloadableTransport(tlsConfig *tls.Config) *reloadableTransport {
+	return &reloadableTransport{transport: newTLSTransport(tlsConfig)}
+}
+
+func (t *reloadableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.lock.RLock()
+	transport := t.transport
+	t.lock.RUnlock()
+
+	return transport.RoundTrip(req)
+}
+
+// swap replaces the TLS configuration, requests in flight complete
+// on the previous transport.
+func (t *reloadableTransport) swap(tlsConfig *tls.Config) {
+	t.lock.Lock()
+	previous := t.transport
+	t.transport = newTLSTransport(tlsConfig)
+	t.lock.Unlock()
+
+	previous.CloseIdleConnections()
+}
+
+// newClusterClient creates a client using httpClient as is,
+// TLS and authentication are set up by newHTTPClient.
+func newClusterClient(httpClient *http.Client, endpoint, apiVersion string) (*clusterClient, error) {
+	client, err := sf.NewClient(httpClient, endpoint, apiVersion, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if apiVersion == "" {
+		apiVersion = sf.DefaultAPIVersion
+	}
+
+	return &clusterClient{
+		client:     client,
+		httpClient: httpClient,
+		endpoint:   endpoint,
+		apiVersion: apiVersion,
+	}, nil
+}
+
+func (c *clusterClient) GetApplications(continuationToken string) (*sf.ApplicationItemsPage, error) {
+	page := &sf.ApplicationItemsPage{}
+	if err := c.getPage("Applications/", continuationToken, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (c *clusterClient) GetServices(appName, continuationToken string) (*sf.ServiceItemsPage, error) {
+	page := &sf.ServiceItemsPage{}
+	if err := c.getPage("Applications/"+appName+"/$/GetServices", continuationToken, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (c *clusterClient) GetPartitions(appName, serviceName, continuationToken string) (*partitionItemsPage, error) {
+	page := &partitionItemsPage{}
+	basePath := "Applications/" + appName + "/$/GetServices/" + serviceName + "/$/GetPartitions/"
+	if err := c.getPage(basePath, continuationToken, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (c *clusterClient) GetReplicas(appName, serviceName, partitionName, continuationToken string) (*sf.ReplicaItemsPage, error) {
+	page := &sf.ReplicaItemsPage{}
+	if err := c.getPage(replicasPath(appName, serviceName, partitionName), continuationToken, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (c *clusterClient) GetInstances(appName, serviceName, partitionName, continuationToken string) (*sf.InstanceItemsPage, error) {
+	page := &sf.InstanceItemsPage{}
+	if err := c.getPage(replicasPath(appName, serviceName, partitionName), continuationToken, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (c *clusterClient) GetNodes(continuationToken string) (*nodeItemsPage, error) {
+	page := &nodeItemsPage{}
+	if err := c.getPage("Nodes", continuationToken, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetUpgradeProgress returns the progress of the last upgrade of the application.
+func (c *clusterClient) GetUpgradeProgress(appName string) (*applicationUpgradeProgress, error) {
+	params := url.Values{}
+	params.Set("api-version", c.apiVersion)
+
+	progress := &applicationUpgradeProgress{}
+	if err := c.get("Applications/"+appName+"/$/GetUpgradeProgress", params, progress); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+func (c *clusterClient) GetServiceExtensionMap(service *sf.ServiceItem, app *sf.ApplicationItem, extensionKey string) (map[string]string, error) {
+	return c.client.GetServiceExtensionMap(service, app, extensionKey)
+}
+
+func (c *clusterClient) GetServiceLabels(service *sf.ServiceItem, app *sf.ApplicationItem, prefix string) (map[string]string, error) {
+	return c.client.GetServiceLabels(service, app, prefix)
+}
+
+func (c *clusterClient) GetProperties(name string) (bool, map[string]string, error) {
+	return c.client.GetProperties(name)
+}
+
+// GetEvents returns the events of the entity recorded between start and end.
+func (c *clusterClient) GetEvents(entity string, start, end time.Time) ([]fabricEvent, error) {
+	params := url.Values{}
+	params.Set("api-version", eventStoreAPIVersion)
+	params.Set("StartTimeUtc", start.UTC().Format(eventStoreTimeFormat))
+	params.Set("EndTimeUtc", end.UTC().Format(eventStoreTimeFormat))
+
+	var events []fabricEvent
+	if err := c.get("EventsStore/"+entity+"/Events", params, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (c *clusterClient) getPage(basePath, continuationToken string, page interface{}) error {
+	params := url.Values{}
+	params.Set("api-version", c.apiVersion)
+	if continuationToken != "" {
+		params.Set("ContinuationToken", continuationToken)
+	}
+	return c.get(basePath, params, page)
+}
+
+func (c *clusterClient) get(basePath string, params url.Values, result interface{}) error {
+	reqURL := c.endpoint + "/" + basePath + "?" + params.Encode()
+
+	resp, err := c.httpClient.Get(reqURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Service Fabric server on %s: %w", reqURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("service fabric responded with status %s to request %s", resp.Status, reqURL)
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("could not deserialize JSON response from %s: %w", reqURL, err)
+	}
+	return nil
+}
+
+func replicasPath(appName, serviceName, partitionName string) string {
+	return "Applications/" + appName + "/$/GetServices/" + serviceName + "/$/GetPartitions/" + partitionName + "/$/GetReplicas"
+}
@@ -0,0 +1,83 @@
+package servicefabric
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterClientGetApplicationsContinuationToken(t *testing.T) {
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/Applications/" {
+			http.NotFound(rw, req)
+			return
+		}
+
+		assert.Equal(t, "6.0", req.URL.Query().Get("api-version"))
+
+		token := req.URL.Query().Get("ContinuationToken")
+		tokens = append(tokens, token)
+
+		switch token {
+		case "":
+			fmt.Fprint(rw, `{"ContinuationToken":"app2","Items":[{"Id":"app1","Name":"fabric:/app1"}]}`)
+		case "app2":
+			fmt.Fprint(rw, `{"ContinuationToken":"","Items":[{"Id":"app2","Name":"fabric:/app2"}]}`)
+		default:
+			http.Error(rw, "unexpected token", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client, err := newClusterClient(&http.Client{}, server.URL, "6.0")
+	require.NoError(t, err)
+
+	apps, err := listApplications(client)
+	require.NoError(t, err)
+
+	require.Len(t, apps, 2)
+	assert.Equal(t, "app1", apps[0].ID)
+	assert.Equal(t, "app2", apps[1].ID)
+	assert.Equal(t, []string{"", "app2"}, tokens)
+}
+
+func TestClusterClientErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, err := newClusterClient(&http.Client{}, server.URL, "")
+	require.NoError(t, err)
+
+	_, err = client.GetServices("app1", "")
+	require.Error(t, err)
+}
+
+func TestClusterClientGetPartitionsNames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"ContinuationToken":"","Items":[
+			{"ServiceKind":"Stateful","PartitionInformation":{"ServicePartitionKind":"Named","Id":"p1","Name":"east"}},
+			{"ServiceKind":"Stateful","PartitionInformation":{"ServicePartitionKind":"Int64Range","Id":"p2","LowKey":"0","HighKey":"9"}}
+		]}`)
+	}))
+	defer server.Close()
+
+	client, err := newClusterClient(&http.Client{}, server.URL, "")
+	require.NoError(t, err)
+
+	partitions, err := listPartitions(client, "app1", "app1/svc1")
+	require.NoError(t, err)
+
+	require.Len(t, partitions, 2)
+	assert.Equal(t, "p1", partitions[0].PartitionInformation.ID)
+	assert.Equal(t, "east", partitions[0].Name)
+	assert.Equal(t, "p2", partitions[1].PartitionInformation.ID)
+	assert.Equal(t, "9", partitions[1].PartitionInformation.HighKey)
+	assert.Empty(t, partitions[1].Name)
+}
@@ -0,0 +1,120 @@
+package servicefabric
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/traefik/traefik/log"
+	"github.com/traefik/traefik/types"
+)
+
+// Cluster holds the connection settings of one of the clusters discovered
+// by the provider.
+type Cluster struct {
+	Name                 string             `description:"Cluster name, prefixes the backend and frontend names" export:"true"`
+	ClusterManagementURL string             `description:"Service Fabric API endpoints, comma separated"`
+	APIVersion           string             `description:"Service Fabric API version" export:"true"`
+	TLS                  *types.ClientTLS   `description:"Enable TLS support" export:"true"`
+	ServerCertificate    *ServerCertificate `description:"Pin the cluster server certificate by thumbprint or common name" export:"true"`
+	AAD                  *AADAuth           `description:"Enable Azure Active Directory authentication" export:"true"`
+	GroupWeight          int                `description:"Multiplies the group weight of the cluster services in service groups" export:"true"`
+}
+
+// clusterConnection is the runtime state of a discovered cluster.
+type clusterConnection struct {
+	name        string
+	groupWeight int
+	client      sfClient
+	appCache    *applicationCache
+	certWatcher *certificateWatcher
+	nodes       map[string]NodeItem
+}
+
+// getClusters returns the configured clusters, the top level connection
+// settings describe a single unnamed cluster when Clusters is empty.
+func (p *Provider) getClusters() ([]*Cluster, error) {
+	if len(p.Clusters) == 0 {
+		return []*Cluster{{
+			ClusterManagementURL: p.ClusterManagementURL,
+			APIVersion:           p.APIVersion,
+			TLS:                  p.TLS,
+			ServerCertificate:    p.ServerCertificate,
+			AAD:                  p.AAD,
+		}}, nil
+	}
+
+	names := make(map[string]struct{}, len(p.Clusters))
+	for _, cluster := range p.Clusters {
+		if cluster == nil || cluster.Name == "" {
+			return nil, errors.New("cluster name must be set when several clusters are configured")
+		}
+		if _, exists := names[cluster.Name]; exists {
+			return nil, fmt.Errorf("duplicate cluster name %q", cluster.Name)
+		}
+		names[cluster.Name] = struct{}{}
+	}
+	return p.Clusters, nil
+}
+
+func newClusterConnection(cluster *Cluster, apiVersion string) (*clusterConnection, error) {
+	if cluster.APIVersion != "" {
+		apiVersion = cluster.APIVersion
+	}
+
+	tlsConfig, err := createTLSConfig(cluster.TLS, cluster.ServerCertificate)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := parseEndpoints(cluster.ClusterManagementURL)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := newReloadableTransport(tlsConfig)
+
+	failover := newFailoverTransport(transport, endpoints)
+	httpClient, err := newHTTPClient(failover, cluster.AAD)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newClusterClient(httpClient, endpoints[0].String(), apiVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Using Service Fabric management endpoint %s%s", failover.activeEndpoint(), clusterLogSuffix(cluster.Name))
+
+	return &clusterConnection{
+		name:        cluster.Name,
+		groupWeight: cluster.GroupWeight,
+		client:      client,
+		appCache:    newApplicationCache(),
+		certWatcher: newCertificateWatcher(cluster.TLS, cluster.ServerCertificate, transport),
+	}, nil
+}
+
+func (p *Provider) getCluster(name string) *clusterConnection {
+	for _, cluster := range p.clusters {
+		if cluster.name == name {
+			return cluster
+		}
+	}
+	return nil
+}
+
+// getClusterGroupWeight returns the group weight multiplier of the named cluster.
+func (p *Provider) getClusterGroupWeight(name string) int {
+	if cluster := p.getCluster(name); cluster != nil && cluster.groupWeight > 0 {
+		return cluster.groupWeight
+	}
+	return 1
+}
+
+func clusterLogSuffix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return " for cluster " + name
+}
@@ -0,0 +1,261 @@
+package servicefabric
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	sf "github.com/jjcollinge/servicefabric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/provider/label"
+	"github.com/traefik/traefik/types"
+)
+
+func TestGetClusters(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		provider      Provider
+		expectedNames []string
+		expectedURLs  []string
+		expectedError bool
+	}{
+		{
+			desc: "top level settings",
+			provider: Provider{
+				ClusterManagementURL: "http://localhost:19080",
+				APIVersion:           "6.0",
+			},
+			expectedNames: []string{""},
+			expectedURLs:  []string{"http://localhost:19080"},
+		},
+		{
+			desc: "named clusters",
+			provider: Provider{
+				ClusterManagementURL: "http://ignored:19080",
+				Clusters: []*Cluster{
+					{Name: "east", ClusterManagementURL: "https://east:19080"},
+					{Name: "west", ClusterManagementURL: "https://west:19080"},
+				},
+			},
+			expectedNames: []string{"east", "west"},
+			expectedURLs:  []string{"https://east:19080", "https://west:19080"},
+		},
+		{
+			desc: "missing name",
+			provider: Provider{
+				Clusters: []*Cluster{
+					{Name: "east", ClusterManagementURL: "https://east:19080"},
+					{ClusterManagementURL: "https://west:19080"},
+				},
+			},
+			expectedError: true,
+		},
+		{
+			desc: "duplicate name",
+			provider: Provider{
+				Clusters: []*Cluster{
+					{Name: "east", ClusterManagementURL: "https://east:19080"},
+					{Name: "east", ClusterManagementURL: "https://west:19080"},
+				},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			clusters, err := test.provider.getClusters()
+			if test.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var names, urls []string
+			for _, cluster := range clusters {
+				names = append(names, cluster.Name)
+				urls = append(urls, cluster.ClusterManagementURL)
+			}
+			assert.Equal(t, test.expectedNames, names)
+			assert.Equal(t, test.expectedURLs, urls)
+		})
+	}
+}
+
+func TestInitNamedClusters(t *testing.T) {
+	provider := Provider{
+		APIVersion: "6.0",
+		Clusters: []*Cluster{
+			{Name: "east", ClusterManagementURL: "http://east:19080", GroupWeight: 3},
+			{Name: "west", ClusterManagementURL: "http://west:19080", APIVersion: "6.4"},
+		},
+	}
+
+	err := provider.Init(types.Constraints{})
+	require.NoError(t, err)
+
+	require.Len(t, provider.clusters, 2)
+	assert.Equal(t, "east", provider.clusters[0].name)
+	assert.Equal(t, "6.0", provider.clusters[0].client.(*clusterClient).apiVersion)
+	assert.Equal(t, "west", provider.clusters[1].name)
+	assert.Equal(t, "6.4", provider.clusters[1].client.(*clusterClient).apiVersion)
+
+	assert.Equal(t, 3, provider.getClusterGroupWeight("east"))
+	assert.Equal(t, 1, provider.getClusterGroupWeight("west"))
+}
+
+func newTestClusterMock() *clientMock {
+	return &clientMock{
+		applications: apps,
+		services:     services,
+		partitions:   partitions,
+		instances:    instances,
+		getServiceExtensionMapResult: map[string]string{
+			label.TraefikEnable: "true",
+		},
+	}
+}
+
+func TestGetServicesMultipleClusters(t *testing.T) {
+	east := newTestClusterMock()
+	west := newTestClusterMock()
+
+	provider := Provider{
+		MaxStaleness: flaeg.Duration(time.Hour),
+		clusters: []*clusterConnection{
+			{name: "east", client: east},
+			{name: "west", client: west},
+		},
+	}
+
+	serviceItems, err := provider.getServices()
+	require.NoError(t, err)
+	require.Len(t, serviceItems, 2)
+	assert.Equal(t, "east", serviceItems[0].Cluster)
+	assert.Equal(t, "west", serviceItems[1].Cluster)
+	assert.Equal(t, serviceItems[0].Name, serviceItems[1].Name)
+
+	west.applicationsError = errors.New("cluster unavailable")
+
+	serviceItems, err = provider.getServices()
+	require.NoError(t, err)
+	require.Len(t, serviceItems, 2)
+	assert.False(t, serviceItems[0].Stale)
+	assert.True(t, serviceItems[1].Stale)
+	assert.Equal(t, "west", serviceItems[1].Cluster)
+}
+
+func TestGetServicesAllClustersUnavailable(t *testing.T) {
+	east := newTestClusterMock()
+	east.applicationsError = errors.New("cluster unavailable")
+	west := newTestClusterMock()
+	west.applicationsError = errors.New("cluster unavailable")
+
+	provider := Provider{
+		clusters: []*clusterConnection{
+			{name: "east", client: east},
+			{name: "west", client: west},
+		},
+	}
+
+	_, err := provider.getServices()
+	require.Error(t, err)
+}
+
+func TestBuildConfigurationMultipleClusters(t *testing.T) {
+	newService := func(cluster, address string) ServiceItemExtended {
+		return ServiceItemExtended{
+			ServiceItem: sf.ServiceItem{
+				ID:          "TestApplication/TestService",
+				Name:        "fabric:/TestApplication/TestService",
+				ServiceKind: kindStateless,
+			},
+			Cluster: cluster,
+			Partitions: []PartitionItemExtended{
+				{
+					PartitionItem: sf.PartitionItem{
+						PartitionInformation: sf.PartitionInformation{ID: "partition"},
+						ServiceKind:          kindStateless,
+					},
+					Instances: []sf.InstanceItem{
+						{
+							ReplicaItemBase: &sf.ReplicaItemBase{
+								Address:       `{"Endpoints":{"":"` + address + `"}}`,
+								HealthState:   "Ok",
+								ReplicaStatus: "Ready",
+								ServiceKind:   kindStateless,
+							},
+							ID: "1",
+						},
+					},
+				},
+			},
+			Labels: map[string]string{
+				label.TraefikEnable:  "true",
+				traefikSFGroupName:   "shared",
+				traefikSFGroupWeight: "10",
+			},
+		}
+	}
+
+	provider := Provider{
+		clusters: []*clusterConnection{
+			{name: "east", groupWeight: 3},
+			{name: "west"},
+		},
+	}
+
+	config, err := provider.buildConfiguration([]ServiceItemExtended{
+		newService("east", "http://east:8080"),
+		newService("west", "http://west:8080"),
+	})
+	require.NoError(t, err)
+
+	expectedBackends := map[string]*types.Backend{
+		"east/fabric:/TestApplication/TestService": {
+			Servers: map[string]types.Server{
+				"1": {URL: "http://east:8080", Weight: label.DefaultWeight},
+			},
+		},
+		"west/fabric:/TestApplication/TestService": {
+			Servers: map[string]types.Server{
+				"1": {URL: "http://west:8080", Weight: label.DefaultWeight},
+			},
+		},
+		"shared": {
+			Servers: map[string]types.Server{
+				"east/TestApplication/TestService-1": {URL: "http://east:8080", Weight: 30},
+				"west/TestApplication/TestService-1": {URL: "http://west:8080", Weight: 10},
+			},
+		},
+	}
+	assert.Equal(t, expectedBackends, config.Backends)
+
+	assert.Contains(t, config.Frontends, "frontend-east/fabric:/TestApplication/TestService")
+	assert.Contains(t, config.Frontends, "frontend-west/fabric:/TestApplication/TestService")
+	assert.Equal(t, "west/fabric:/TestApplication/TestService", config.Frontends["frontend-west/fabric:/TestApplication/TestService"].Backend)
+}
+
+func TestGetBackendNameWithCluster(t *testing.T) {
+	partition := PartitionItemExtended{
+		PartitionItem: sf.PartitionItem{
+			PartitionInformation: sf.PartitionInformation{ID: "bce46a8c"},
+		},
+	}
+	service := ServiceItemExtended{
+		ServiceItem: sf.ServiceItem{Name: "fabric:/TestApplication/TestService"},
+	}
+
+	unnamed := getBackendName(service, partition)
+
+	service.Cluster = "east"
+	named := getBackendName(service, partition)
+
+	assert.NotEqual(t, unnamed, named)
+	assert.Equal(t, "east-fabric-TestApplication-TestServicebce46a8c", named)
+}
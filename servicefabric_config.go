@@ -20,23 +20,30 @@ func (p *Provider) buildConfiguration(services []ServiceItemExtended) (*types.Co
 		"getLabelValue":              getServiceStringLabel,
 		"getLabelsWithPrefix":        getServiceLabelsWithPrefix,
 		"isPrimary":                  isPrimary,
+		"isActiveSecondary":          isActiveSecondary,
 		"isStateful":                 isStateful,
 		"isStateless":                isStateless,
 		"isEnabled":                  getFuncBoolLabel(label.TraefikEnable, false),
+		"getServiceName":             getServiceName,
+		"getServiceID":               getServiceID,
 		"getBackendName":             getBackendName,
-		"getDefaultEndpoint":         getDefaultEndpoint,
+		"getPartitionRule":           getPartitionRule,
+		"getEndpointName":            getEndpointName,
+		"getDefaultEndpoint":         p.getDefaultEndpoint,
 		"getNamedEndpoint":           getNamedEndpoint,           // TODO unused
 		"getApplicationParameter":    getApplicationParameter,    // TODO unused
 		"doesAppParamContain":        doesAppParamContain,        // TODO unused
 		"filterServicesByLabelValue": filterServicesByLabelValue, // TODO unused
 
 		// Backend functions
-		"getWeight":         getFuncServiceIntLabel(label.TraefikWeight, label.DefaultWeight),
-		"getProtocol":       getFuncServiceStringLabel(label.TraefikProtocol, label.DefaultProtocol),
-		"getMaxConn":        getMaxConn,
-		"getHealthCheck":    getHealthCheck,
-		"getCircuitBreaker": getCircuitBreaker,
-		"getLoadBalancer":   getLoadBalancer,
+		"getWeight":                getFuncServiceIntLabel(label.TraefikWeight, label.DefaultWeight),
+		"getNodeWeight":            p.getNodeWeight,
+		"getProtocol":              getFuncServiceStringLabel(label.TraefikProtocol, label.DefaultProtocol),
+		"getMaxConn":               getMaxConn,
+		"getHealthCheck":           getHealthCheck,
+		"getCircuitBreaker":        getCircuitBreaker,
+		"getLoadBalancer":          getLoadBalancer,
+		"getPartitionLoadBalancer": getPartitionLoadBalancer,
 
 		// Frontend Functions
 		"getPriority":       getFuncServiceIntLabel(label.TraefikFrontendPriority, label.DefaultFrontendPriority),
@@ -50,15 +57,26 @@ func (p *Provider) buildConfiguration(services []ServiceItemExtended) (*types.Co
 		"getRedirect":       getRedirect,
 		"getErrorPages":     getErrorPages,
 
+		// SF Read Secondaries
+		"hasReadSecondaries":        hasReadSecondaries,
+		"getSecondariesBackendName": getSecondariesBackendName,
+		"getSecondariesRule":        getFuncServiceStringLabel(traefikSFSecondariesRule, defaultSecondariesRule),
+		"getSecondariesPriority":    getSecondariesPriority,
+
 		// SF Service Grouping
-		"getGroupedServices": getFuncServicesGroupedByLabel(traefikSFGroupName),
-		"getGroupedWeight":   getFuncServiceStringLabel(traefikSFGroupWeight, "1"),
+		"getGroupedServices": getGroupedServices,
+		"getGroupPriority":   getGroupPriority,
+		"isGroupReplica":     isGroupReplica,
+		"isGroupOnly":        isGroupOnly,
+		"getGroupedWeight":   p.getGroupedWeight,
 	}
 
 	templateObjects := struct {
 		Services []ServiceItemExtended
+		Groups   []serviceGroup
 	}{
-		Services: services,
+		Services: getSegmentServices(services),
+		Groups:   getServiceGroups(services),
 	}
 
 	return p.GetConfiguration(tmpl, sfFuncMap, templateObjects)
@@ -69,13 +87,71 @@ func isPrimary(instance replicaInstance) bool {
 	return data.ReplicaRole == "Primary"
 }
 
+// getServiceName returns the service name prefixed with the cluster name
+// and suffixed with the label segment name,
+// it is unique across the discovered clusters.
+func getServiceName(service ServiceItemExtended) string {
+	name := service.Name
+	if service.Cluster != "" {
+		name = service.Cluster + "/" + name
+	}
+	if service.Segment != "" {
+		name += "/" + service.Segment
+	}
+	return name
+}
+
+// getServiceID returns the service ID prefixed with the cluster name.
+func getServiceID(service ServiceItemExtended) string {
+	if service.Cluster == "" {
+		return service.ID
+	}
+	return service.Cluster + "/" + service.ID
+}
+
+func isActiveSecondary(instance replicaInstance) bool {
+	_, data := instance.GetReplicaData()
+	return data.ReplicaRole == "ActiveSecondary"
+}
+
+// hasReadSecondaries reports whether the read requests to the partition
+// are sent to its active secondary replicas.
+func hasReadSecondaries(service ServiceItemExtended, partition PartitionItemExtended) bool {
+	if label.GetStringValue(service.Labels, traefikSFSecondaries, "") != secondariesRead {
+		return false
+	}
+
+	for i := range partition.Replicas {
+		if isActiveSecondary(&partition.Replicas[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func getSecondariesBackendName(service ServiceItemExtended, partition PartitionItemExtended) string {
+	return getBackendName(service, partition) + "-secondaries"
+}
+
+// getSecondariesPriority places the secondaries frontend before
+// the frontend of the primary replica.
+func getSecondariesPriority(service ServiceItemExtended) int {
+	return label.GetIntValue(service.Labels, label.TraefikFrontendPriority, label.DefaultFrontendPriority) + 1
+}
+
 func getBackendName(service ServiceItemExtended, partition PartitionItemExtended) string {
-	return provider.Normalize(service.Name + partition.PartitionInformation.ID)
+	return provider.Normalize(getServiceName(service) + partition.PartitionInformation.ID)
+}
+
+// getGroupedWeight multiplies the group weight label of the service
+// by the group weight of its cluster.
+func (p *Provider) getGroupedWeight(service ServiceItemExtended) int {
+	return label.GetIntValue(service.Labels, traefikSFGroupWeight, 1) * p.getClusterGroupWeight(service.Cluster)
 }
 
-func getDefaultEndpoint(instance replicaInstance) string {
+func (p *Provider) getDefaultEndpoint(instance replicaInstance) string {
 	id, data := instance.GetReplicaData()
-	endpoint, err := getReplicaDefaultEndpoint(data)
+	endpoint, err := getReplicaDefaultEndpoint(data, p.PreferredEndpoints)
 	if err != nil {
 		log.Warnf("No default endpoint for replica %s in service %s endpointData: %s", id, data.Address, err)
 		return ""
@@ -103,12 +179,15 @@ func getReplicaNamedEndpoint(replicaData *sf.ReplicaItemBase, endpointName strin
 	if !exists {
 		return "", errors.New("endpoint doesn't exist")
 	}
+	if _, err := parseHTTPEndpoint(endpoint); err != nil {
+		return "", err
+	}
 	return endpoint, nil
 }
 
 func getApplicationParameter(app sf.ApplicationItem, key string) string {
 	for _, param := range app.Parameters {
-		if param.Key == key {
+		if param != nil && param.Key == key {
 			return param.Value
 		}
 	}
@@ -174,6 +253,17 @@ func getLoadBalancer(service ServiceItemExtended) *types.LoadBalancer {
 	return label.GetLoadBalancer(service.Labels)
 }
 
+const defaultPartitionLoadBalancerMethod = "drr"
+
+// getPartitionLoadBalancer returns the load balancer of the stateful partition
+// backends, which use the drr method when no load balancer label is set.
+func getPartitionLoadBalancer(service ServiceItemExtended) *types.LoadBalancer {
+	if lb := getLoadBalancer(service); lb != nil {
+		return lb
+	}
+	return &types.LoadBalancer{Method: defaultPartitionLoadBalancerMethod}
+}
+
 func getErrorPages(service ServiceItemExtended) map[string]*types.ErrorPage {
 	return label.GetErrorPages(service.Labels)
 }
@@ -2,6 +2,7 @@ package servicefabric
 
 import (
 	"encoding/json"
+	"math"
 	"testing"
 
 	sf "github.com/jjcollinge/servicefabric"
@@ -163,7 +164,8 @@ func TestBuildConfigurationStateful(t *testing.T) {
 				},
 				Frontends: map[string]*types.Frontend{
 					"fabric:/TestApplication/TestService/bce46a8c-b62d-4996-89dc-7ffc00a96902": {
-						Backend: "fabric-TestApplication-TestServicebce46a8c-b62d-4996-89dc-7ffc00a96902",
+						Backend:        "fabric-TestApplication-TestServicebce46a8c-b62d-4996-89dc-7ffc00a96902",
+						PassHostHeader: true,
 						Routes: map[string]types.Route{
 							"default": {
 								Rule: "HeadersRegexp: username, ^b",
@@ -173,6 +175,40 @@ func TestBuildConfigurationStateful(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "with label partitionkey.header",
+			labels: map[string]string{
+				label.TraefikEnable:         "true",
+				label.TraefikFrontendRule:   "Host:stateful.com",
+				traefikSFPartitionKeyHeader: "X-Partition-Key",
+			},
+			expected: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"fabric-TestApplication-TestServicebce46a8c-b62d-4996-89dc-7ffc00a96902": {
+						LoadBalancer: &types.LoadBalancer{
+							Method: "drr",
+						},
+						Servers: map[string]types.Server{
+							"131496928082309293": {
+								URL:    "http://localhost:8081",
+								Weight: 1,
+							},
+						},
+					},
+				},
+				Frontends: map[string]*types.Frontend{
+					"fabric:/TestApplication/TestService/bce46a8c-b62d-4996-89dc-7ffc00a96902": {
+						Backend:        "fabric-TestApplication-TestServicebce46a8c-b62d-4996-89dc-7ffc00a96902",
+						PassHostHeader: true,
+						Routes: map[string]types.Route{
+							"default": {
+								Rule: "Host:stateful.com;HeadersRegexp:X-Partition-Key,^" + int64RangePattern(math.MinInt64, math.MaxInt64) + "$",
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range testCases {
@@ -242,6 +278,177 @@ func TestBuildConfigurationStateful(t *testing.T) {
 	}
 }
 
+func TestBuildConfigurationStatefulSecondaries(t *testing.T) {
+	provider := Provider{}
+
+	primaryBackend := &types.Backend{
+		LoadBalancer: &types.LoadBalancer{
+			Method: "drr",
+		},
+		Servers: map[string]types.Server{
+			"1": {
+				URL:    "http://localhost:8081",
+				Weight: 1,
+			},
+		},
+	}
+	secondariesBackend := &types.Backend{
+		LoadBalancer: &types.LoadBalancer{
+			Method: "drr",
+		},
+		Servers: map[string]types.Server{
+			"2": {
+				URL:    "http://localhost:8082",
+				Weight: 1,
+			},
+		},
+	}
+	primaryFrontend := &types.Frontend{
+		Backend:        "fabric-TestApplication-TestServicebce46a8c-b62d-4996-89dc-7ffc00a96902",
+		PassHostHeader: true,
+		Routes: map[string]types.Route{
+			"default": {
+				Rule: "Host:stateful.com",
+			},
+		},
+	}
+
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *types.Configuration
+	}{
+		{
+			desc: "without secondaries label",
+			labels: map[string]string{
+				label.TraefikEnable: "true",
+				"traefik.frontend.rule.partition.bce46a8c-b62d-4996-89dc-7ffc00a96902": "Host:stateful.com",
+			},
+			expected: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"fabric-TestApplication-TestServicebce46a8c-b62d-4996-89dc-7ffc00a96902": primaryBackend,
+				},
+				Frontends: map[string]*types.Frontend{
+					"fabric:/TestApplication/TestService/bce46a8c-b62d-4996-89dc-7ffc00a96902": primaryFrontend,
+				},
+			},
+		},
+		{
+			desc: "with read secondaries",
+			labels: map[string]string{
+				label.TraefikEnable:  "true",
+				traefikSFSecondaries: "read",
+				"traefik.frontend.rule.partition.bce46a8c-b62d-4996-89dc-7ffc00a96902": "Host:stateful.com",
+			},
+			expected: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"fabric-TestApplication-TestServicebce46a8c-b62d-4996-89dc-7ffc00a96902":             primaryBackend,
+					"fabric-TestApplication-TestServicebce46a8c-b62d-4996-89dc-7ffc00a96902-secondaries": secondariesBackend,
+				},
+				Frontends: map[string]*types.Frontend{
+					"fabric:/TestApplication/TestService/bce46a8c-b62d-4996-89dc-7ffc00a96902": primaryFrontend,
+					"fabric:/TestApplication/TestService/bce46a8c-b62d-4996-89dc-7ffc00a96902/secondaries": {
+						Backend:        "fabric-TestApplication-TestServicebce46a8c-b62d-4996-89dc-7ffc00a96902-secondaries",
+						PassHostHeader: true,
+						Priority:       1,
+						Routes: map[string]types.Route{
+							"default": {
+								Rule: "Host:stateful.com",
+							},
+							"secondaries": {
+								Rule: "Method:GET,HEAD",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "with read secondaries selected by header",
+			labels: map[string]string{
+				label.TraefikEnable:      "true",
+				traefikSFSecondaries:     "read",
+				traefikSFSecondariesRule: "Headers:X-Read-Only,true",
+				"traefik.frontend.rule.partition.bce46a8c-b62d-4996-89dc-7ffc00a96902": "Host:stateful.com",
+			},
+			expected: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"fabric-TestApplication-TestServicebce46a8c-b62d-4996-89dc-7ffc00a96902":             primaryBackend,
+					"fabric-TestApplication-TestServicebce46a8c-b62d-4996-89dc-7ffc00a96902-secondaries": secondariesBackend,
+				},
+				Frontends: map[string]*types.Frontend{
+					"fabric:/TestApplication/TestService/bce46a8c-b62d-4996-89dc-7ffc00a96902": primaryFrontend,
+					"fabric:/TestApplication/TestService/bce46a8c-b62d-4996-89dc-7ffc00a96902/secondaries": {
+						Backend:        "fabric-TestApplication-TestServicebce46a8c-b62d-4996-89dc-7ffc00a96902-secondaries",
+						PassHostHeader: true,
+						Priority:       1,
+						Routes: map[string]types.Route{
+							"default": {
+								Rule: "Host:stateful.com",
+							},
+							"secondaries": {
+								Rule: "Headers:X-Read-Only,true",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			services := []ServiceItemExtended{
+				{
+					ServiceItem: sf.ServiceItem{
+						ID:          "TestApplication/TestService",
+						Name:        "fabric:/TestApplication/TestService",
+						ServiceKind: kindStateful,
+					},
+					Partitions: []PartitionItemExtended{
+						{
+							PartitionItem: sf.PartitionItem{
+								PartitionInformation: sf.PartitionInformation{
+									ID:                   "bce46a8c-b62d-4996-89dc-7ffc00a96902",
+									ServicePartitionKind: "Singleton",
+								},
+								ServiceKind: kindStateful,
+							},
+							Replicas: []sf.ReplicaItem{
+								newTestReplica("1", "Primary", "http://localhost:8081"),
+								newTestReplica("2", "ActiveSecondary", "http://localhost:8082"),
+								newTestReplica("3", "IdleSecondary", "http://localhost:8083"),
+							},
+						},
+					},
+					Labels: test.labels,
+				},
+			}
+
+			config, err := provider.buildConfiguration(services)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, config)
+		})
+	}
+}
+
+func newTestReplica(id, role, address string) sf.ReplicaItem {
+	return sf.ReplicaItem{
+		ReplicaItemBase: &sf.ReplicaItemBase{
+			Address:       `{"Endpoints":{"":"` + address + `"}}`,
+			HealthState:   "Ok",
+			ReplicaRole:   role,
+			ReplicaStatus: "Ready",
+			ServiceKind:   kindStateful,
+		},
+		ID: id,
+	}
+}
+
 func TestBuildConfigurationFrontendLabelConfig(t *testing.T) {
 	testCases := []struct {
 		desc     string
@@ -612,89 +819,112 @@ func TestBuildConfigurationFrontendLabelConfig(t *testing.T) {
 	}
 }
 
-func TestBuildConfigurationBackendLabelConfig(t *testing.T) {
+func TestBuildConfigurationStatefulFrontendLabelConfig(t *testing.T) {
 	testCases := []struct {
 		desc     string
 		labels   map[string]string
-		validate func(*testing.T, *types.Backend)
+		validate func(*testing.T, *types.Frontend)
 	}{
 		{
-			desc: "Has DRR LoadBalancer",
+			desc: "Has passHostHeader disabled",
 			labels: map[string]string{
-				label.TraefikEnable:                    "true",
-				label.TraefikBackendLoadBalancerMethod: "drr",
+				label.TraefikFrontendPassHostHeader: "false",
 			},
-			validate: func(t *testing.T, b *types.Backend) {
-				t.Helper()
-				require.NotNil(t, b.LoadBalancer, "LoadBalancer")
-				assert.Equal(t, "drr", b.LoadBalancer.Method)
+			validate: func(t *testing.T, f *types.Frontend) {
+				assert.False(t, f.PassHostHeader)
 			},
 		},
 		{
-			desc: "Has health check set",
+			desc: "Has passTLSCert enabled",
 			labels: map[string]string{
-				label.TraefikEnable:                     "true",
-				label.TraefikBackendHealthCheckPath:     "/hc",
-				label.TraefikBackendHealthCheckPort:     "9000",
-				label.TraefikBackendHealthCheckInterval: "1337s",
-				label.TraefikBackendHealthCheckHostname: "foo.com",
-				label.TraefikBackendHealthCheckHeaders:  "Foo:bar || Bar:foo",
+				label.TraefikFrontendPassTLSCert: "true",
 			},
-			validate: func(t *testing.T, b *types.Backend) {
-				t.Helper()
-				expected := &types.HealthCheck{
-					Path:     "/hc",
-					Port:     9000,
-					Interval: "1337s",
-					Hostname: "foo.com",
-					Headers: map[string]string{
-						"Foo": "bar",
-						"Bar": "foo",
-					},
-				}
-				assert.Equal(t, expected, b.HealthCheck)
+			validate: func(t *testing.T, f *types.Frontend) {
+				assert.True(t, f.PassTLSCert)
 			},
 		},
 		{
-			desc: "Has circuit breaker set",
+			desc: "Has entrypoints set",
 			labels: map[string]string{
-				label.TraefikEnable:                          "true",
-				label.TraefikBackendCircuitBreakerExpression: "NetworkErrorRatio() > 0.5",
+				label.TraefikFrontendEntryPoints: "Barlabel,Bazlabel",
 			},
-			validate: func(t *testing.T, b *types.Backend) {
-				t.Helper()
-				expected := &types.CircuitBreaker{
-					Expression: "NetworkErrorRatio() > 0.5",
-				}
-				assert.Equal(t, expected, b.CircuitBreaker)
+			validate: func(t *testing.T, f *types.Frontend) {
+				assert.Equal(t, []string{"Barlabel", "Bazlabel"}, f.EntryPoints)
 			},
 		},
 		{
-			desc: "Has stickiness cookie set",
+			desc: "Has basicAuth set",
 			labels: map[string]string{
-				label.TraefikEnable:                        "true",
-				label.TraefikBackendLoadBalancerStickiness: "true",
+				label.TraefikFrontendAuthBasic: "USER1:HASH1, USER2:HASH2",
 			},
-			validate: func(t *testing.T, b *types.Backend) {
-				t.Helper()
-				require.NotNil(t, b.LoadBalancer, "LoadBalancer")
-				assert.NotNil(t, b.LoadBalancer.Stickiness, "Stickiness")
+			validate: func(t *testing.T, f *types.Frontend) {
+				assert.EqualValues(t, []string{"USER1:HASH1", "USER2:HASH2"}, f.BasicAuth)
 			},
 		},
 		{
-			desc: "Has maxconn amount and extractor func",
+			desc: "Has whitelist set",
 			labels: map[string]string{
-				label.TraefikEnable:                      "true",
-				label.TraefikBackendMaxConnAmount:        "1337",
-				label.TraefikBackendMaxConnExtractorFunc: "request.header.TEST_HEADER",
+				label.TraefikFrontendWhiteListSourceRange: "10.0.0.1,10.0.0.2",
 			},
-			validate: func(t *testing.T, b *types.Backend) {
-				t.Helper()
-				expected := &types.MaxConn{
-					Amount:        1337,
-					ExtractorFunc: "request.header.TEST_HEADER",
+			validate: func(t *testing.T, f *types.Frontend) {
+				require.NotNil(t, f.WhiteList)
+				assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, f.WhiteList.SourceRange)
+			},
+		},
+		{
+			desc: "Has redirect set",
+			labels: map[string]string{
+				label.TraefikFrontendRedirectEntryPoint: "https",
+			},
+			validate: func(t *testing.T, f *types.Frontend) {
+				require.NotNil(t, f.Redirect)
+				assert.Equal(t, "https", f.Redirect.EntryPoint)
+			},
+		},
+		{
+			desc: "Has error pages set",
+			labels: map[string]string{
+				label.Prefix + label.BaseFrontendErrorPage + "foo." + label.SuffixErrorPageStatus:  "404",
+				label.Prefix + label.BaseFrontendErrorPage + "foo." + label.SuffixErrorPageBackend: "fabric:/TestApplication/ErrorService",
+				label.Prefix + label.BaseFrontendErrorPage + "foo." + label.SuffixErrorPageQuery:   "/404.html",
+			},
+			validate: func(t *testing.T, f *types.Frontend) {
+				expected := map[string]*types.ErrorPage{
+					"foo": {
+						Status:  []string{"404"},
+						Backend: "fabric:/TestApplication/ErrorService",
+						Query:   "/404.html",
+					},
 				}
-				assert.Equal(t, expected, b.MaxConn)
+				assert.Equal(t, expected, f.Errors)
+			},
+		},
+		{
+			desc: "Has headers set",
+			labels: map[string]string{
+				label.TraefikFrontendSSLRedirect:           "true",
+				label.TraefikFrontendRequestHeaders:        "X-Custom-Header:test",
+				label.TraefikFrontendSTSSeconds:            "666",
+				label.TraefikFrontendFrameDeny:             "true",
+				label.TraefikFrontendContentTypeNosniff:    "true",
+				label.TraefikFrontendBrowserXSSFilter:      "true",
+				label.TraefikFrontendIsDevelopment:         "true",
+				label.TraefikFrontendContentSecurityPolicy: "default-src 'self'",
+			},
+			validate: func(t *testing.T, f *types.Frontend) {
+				expected := &types.Headers{
+					CustomRequestHeaders: map[string]string{
+						"X-Custom-Header": "test",
+					},
+					SSLRedirect:           true,
+					STSSeconds:            666,
+					FrameDeny:             true,
+					ContentTypeNosniff:    true,
+					BrowserXSSFilter:      true,
+					ContentSecurityPolicy: "default-src 'self'",
+					IsDevelopment:         true,
+				}
+				assert.Equal(t, expected, f.Headers)
 			},
 		},
 	}
@@ -706,42 +936,236 @@ func TestBuildConfigurationBackendLabelConfig(t *testing.T) {
 
 			provider := Provider{}
 
+			labels := map[string]string{
+				label.TraefikEnable:         "true",
+				traefikSFSecondaries:        "read",
+				traefikSFPartitionKeyHeader: "X-Partition-Key",
+			}
+			for key, value := range test.labels {
+				labels[key] = value
+			}
+
 			services := []ServiceItemExtended{
 				{
 					ServiceItem: sf.ServiceItem{
-						HasPersistedState: true,
-						HealthState:       "Ok",
-						ID:                "TestApplication/TestService",
-						IsServiceGroup:    false,
-						ManifestVersion:   "1.0.0",
-						Name:              "fabric:/TestApplication/TestService",
-						ServiceKind:       kindStateless,
-						ServiceStatus:     "Active",
-						TypeName:          "TestServiceType",
-					},
-					Application: sf.ApplicationItem{
-						HealthState: "Ok",
-						ID:          "TestApplication",
-						Name:        "fabric:/TestApplication",
-						Parameters: []*sf.AppParameter{
-							{
-								Key:   "TraefikPublish",
-								Value: "fabric:/TestApplication/TestService",
-							},
-						},
-						Status:      "Ready",
-						TypeName:    "TestApplicationType",
-						TypeVersion: "1.0.0",
+						ID:          "TestApplication/TestService",
+						Name:        "fabric:/TestApplication/TestService",
+						ServiceKind: kindStateful,
 					},
 					Partitions: []PartitionItemExtended{
 						{
 							PartitionItem: sf.PartitionItem{
-								CurrentConfigurationEpoch: sf.ConfigurationEpoch{
-									ConfigurationVersion: "12884901891",
-									DataLossVersion:      "131496928071680379",
-								},
-								HealthState:       "Ok",
-								MinReplicaSetSize: 1,
+								PartitionInformation: sf.PartitionInformation{
+									ID:                   "bce46a8c-b62d-4996-89dc-7ffc00a96902",
+									LowKey:               "0",
+									HighKey:              "99",
+									ServicePartitionKind: partitionKindInt64Range,
+								},
+								ServiceKind: kindStateful,
+							},
+							Replicas: []sf.ReplicaItem{
+								newTestReplica("1", "Primary", "http://localhost:8081"),
+								newTestReplica("2", "ActiveSecondary", "http://localhost:8082"),
+							},
+						},
+					},
+					Labels: labels,
+				},
+			}
+
+			config, err := provider.buildConfiguration(services)
+			require.NoError(t, err)
+
+			require.Len(t, config.Frontends, 2, "Primary and secondaries frontends should be present in the configuration")
+
+			for fname, frontend := range config.Frontends {
+				require.NotNil(t, frontend, "Frontend %s is nil", fname)
+
+				test.validate(t, frontend)
+				if t.Failed() {
+					t.Log(getJSON(frontend))
+				}
+			}
+		})
+	}
+}
+
+func TestBuildConfigurationStatefulFrontendPriority(t *testing.T) {
+	provider := Provider{}
+
+	services := []ServiceItemExtended{
+		{
+			ServiceItem: sf.ServiceItem{
+				ID:          "TestApplication/TestService",
+				Name:        "fabric:/TestApplication/TestService",
+				ServiceKind: kindStateful,
+			},
+			Partitions: []PartitionItemExtended{
+				{
+					PartitionItem: sf.PartitionItem{
+						PartitionInformation: sf.PartitionInformation{
+							ID:                   "bce46a8c-b62d-4996-89dc-7ffc00a96902",
+							ServicePartitionKind: partitionKindSingleton,
+						},
+						ServiceKind: kindStateful,
+					},
+					Replicas: []sf.ReplicaItem{
+						newTestReplica("1", "Primary", "http://localhost:8081"),
+						newTestReplica("2", "ActiveSecondary", "http://localhost:8082"),
+					},
+				},
+			},
+			Labels: map[string]string{
+				label.TraefikEnable:           "true",
+				label.TraefikFrontendPriority: "13",
+				traefikSFSecondaries:          "read",
+				"traefik.frontend.rule.partition.bce46a8c-b62d-4996-89dc-7ffc00a96902": "Host:stateful.com",
+			},
+		},
+	}
+
+	config, err := provider.buildConfiguration(services)
+	require.NoError(t, err)
+
+	primary := config.Frontends["fabric:/TestApplication/TestService/bce46a8c-b62d-4996-89dc-7ffc00a96902"]
+	require.NotNil(t, primary)
+	assert.Equal(t, 13, primary.Priority)
+
+	secondaries := config.Frontends["fabric:/TestApplication/TestService/bce46a8c-b62d-4996-89dc-7ffc00a96902/secondaries"]
+	require.NotNil(t, secondaries)
+	assert.Equal(t, 14, secondaries.Priority)
+}
+
+func TestBuildConfigurationBackendLabelConfig(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		validate func(*testing.T, *types.Backend)
+	}{
+		{
+			desc: "Has DRR LoadBalancer",
+			labels: map[string]string{
+				label.TraefikEnable:                    "true",
+				label.TraefikBackendLoadBalancerMethod: "drr",
+			},
+			validate: func(t *testing.T, b *types.Backend) {
+				t.Helper()
+				require.NotNil(t, b.LoadBalancer, "LoadBalancer")
+				assert.Equal(t, "drr", b.LoadBalancer.Method)
+			},
+		},
+		{
+			desc: "Has health check set",
+			labels: map[string]string{
+				label.TraefikEnable:                     "true",
+				label.TraefikBackendHealthCheckPath:     "/hc",
+				label.TraefikBackendHealthCheckPort:     "9000",
+				label.TraefikBackendHealthCheckInterval: "1337s",
+				label.TraefikBackendHealthCheckHostname: "foo.com",
+				label.TraefikBackendHealthCheckHeaders:  "Foo:bar || Bar:foo",
+			},
+			validate: func(t *testing.T, b *types.Backend) {
+				t.Helper()
+				expected := &types.HealthCheck{
+					Path:     "/hc",
+					Port:     9000,
+					Interval: "1337s",
+					Hostname: "foo.com",
+					Headers: map[string]string{
+						"Foo": "bar",
+						"Bar": "foo",
+					},
+				}
+				assert.Equal(t, expected, b.HealthCheck)
+			},
+		},
+		{
+			desc: "Has circuit breaker set",
+			labels: map[string]string{
+				label.TraefikEnable:                          "true",
+				label.TraefikBackendCircuitBreakerExpression: "NetworkErrorRatio() > 0.5",
+			},
+			validate: func(t *testing.T, b *types.Backend) {
+				t.Helper()
+				expected := &types.CircuitBreaker{
+					Expression: "NetworkErrorRatio() > 0.5",
+				}
+				assert.Equal(t, expected, b.CircuitBreaker)
+			},
+		},
+		{
+			desc: "Has stickiness cookie set",
+			labels: map[string]string{
+				label.TraefikEnable:                        "true",
+				label.TraefikBackendLoadBalancerStickiness: "true",
+			},
+			validate: func(t *testing.T, b *types.Backend) {
+				t.Helper()
+				require.NotNil(t, b.LoadBalancer, "LoadBalancer")
+				assert.NotNil(t, b.LoadBalancer.Stickiness, "Stickiness")
+			},
+		},
+		{
+			desc: "Has maxconn amount and extractor func",
+			labels: map[string]string{
+				label.TraefikEnable:                      "true",
+				label.TraefikBackendMaxConnAmount:        "1337",
+				label.TraefikBackendMaxConnExtractorFunc: "request.header.TEST_HEADER",
+			},
+			validate: func(t *testing.T, b *types.Backend) {
+				t.Helper()
+				expected := &types.MaxConn{
+					Amount:        1337,
+					ExtractorFunc: "request.header.TEST_HEADER",
+				}
+				assert.Equal(t, expected, b.MaxConn)
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			provider := Provider{}
+
+			services := []ServiceItemExtended{
+				{
+					ServiceItem: sf.ServiceItem{
+						HasPersistedState: true,
+						HealthState:       "Ok",
+						ID:                "TestApplication/TestService",
+						IsServiceGroup:    false,
+						ManifestVersion:   "1.0.0",
+						Name:              "fabric:/TestApplication/TestService",
+						ServiceKind:       kindStateless,
+						ServiceStatus:     "Active",
+						TypeName:          "TestServiceType",
+					},
+					Application: sf.ApplicationItem{
+						HealthState: "Ok",
+						ID:          "TestApplication",
+						Name:        "fabric:/TestApplication",
+						Parameters: []*sf.AppParameter{
+							{
+								Key:   "TraefikPublish",
+								Value: "fabric:/TestApplication/TestService",
+							},
+						},
+						Status:      "Ready",
+						TypeName:    "TestApplicationType",
+						TypeVersion: "1.0.0",
+					},
+					Partitions: []PartitionItemExtended{
+						{
+							PartitionItem: sf.PartitionItem{
+								CurrentConfigurationEpoch: sf.ConfigurationEpoch{
+									ConfigurationVersion: "12884901891",
+									DataLossVersion:      "131496928071680379",
+								},
+								HealthState:       "Ok",
+								MinReplicaSetSize: 1,
 								PartitionInformation: sf.PartitionInformation{
 									HighKey:              "9223372036854775807",
 									ID:                   "bce46a8c-b62d-4996-89dc-7ffc00a96902",
@@ -769,85 +1193,747 @@ func TestBuildConfigurationBackendLabelConfig(t *testing.T) {
 							},
 						},
 					},
-					Labels: test.labels,
+					Labels: test.labels,
+				},
+			}
+
+			config, err := provider.buildConfiguration(services)
+			require.NoError(t, err)
+
+			assert.NotEmpty(t, config.Backends, "No backends present in the configuration")
+
+			for bname, backend := range config.Backends {
+				require.NotNil(t, backend, "Backend %s is nil", bname)
+
+				test.validate(t, backend)
+				if t.Failed() {
+					t.Log(getJSON(backend))
+				}
+			}
+		})
+	}
+}
+
+func TestBuildConfigurationStatefulBackendLabelConfig(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		validate func(*testing.T, *types.Backend)
+	}{
+		{
+			desc:   "Has DRR LoadBalancer by default",
+			labels: map[string]string{},
+			validate: func(t *testing.T, b *types.Backend) {
+				t.Helper()
+				assert.Equal(t, &types.LoadBalancer{Method: "drr"}, b.LoadBalancer)
+			},
+		},
+		{
+			desc: "Has WRR LoadBalancer",
+			labels: map[string]string{
+				label.TraefikBackendLoadBalancerMethod: "wrr",
+			},
+			validate: func(t *testing.T, b *types.Backend) {
+				t.Helper()
+				require.NotNil(t, b.LoadBalancer, "LoadBalancer")
+				assert.Equal(t, "wrr", b.LoadBalancer.Method)
+			},
+		},
+		{
+			desc: "Has weight set",
+			labels: map[string]string{
+				label.TraefikWeight: "7",
+			},
+			validate: func(t *testing.T, b *types.Backend) {
+				t.Helper()
+				for _, server := range b.Servers {
+					assert.Equal(t, 7, server.Weight)
+				}
+			},
+		},
+		{
+			desc: "Has health check set",
+			labels: map[string]string{
+				label.TraefikBackendHealthCheckPath:     "/hc",
+				label.TraefikBackendHealthCheckPort:     "9000",
+				label.TraefikBackendHealthCheckInterval: "1337s",
+				label.TraefikBackendHealthCheckHostname: "foo.com",
+				label.TraefikBackendHealthCheckHeaders:  "Foo:bar || Bar:foo",
+			},
+			validate: func(t *testing.T, b *types.Backend) {
+				t.Helper()
+				expected := &types.HealthCheck{
+					Path:     "/hc",
+					Port:     9000,
+					Interval: "1337s",
+					Hostname: "foo.com",
+					Headers: map[string]string{
+						"Foo": "bar",
+						"Bar": "foo",
+					},
+				}
+				assert.Equal(t, expected, b.HealthCheck)
+			},
+		},
+		{
+			desc: "Has circuit breaker set",
+			labels: map[string]string{
+				label.TraefikBackendCircuitBreakerExpression: "NetworkErrorRatio() > 0.5",
+			},
+			validate: func(t *testing.T, b *types.Backend) {
+				t.Helper()
+				expected := &types.CircuitBreaker{
+					Expression: "NetworkErrorRatio() > 0.5",
+				}
+				assert.Equal(t, expected, b.CircuitBreaker)
+			},
+		},
+		{
+			desc: "Has stickiness cookie set",
+			labels: map[string]string{
+				label.TraefikBackendLoadBalancerStickiness:           "true",
+				label.TraefikBackendLoadBalancerStickinessCookieName: "partition",
+			},
+			validate: func(t *testing.T, b *types.Backend) {
+				t.Helper()
+				require.NotNil(t, b.LoadBalancer, "LoadBalancer")
+				require.NotNil(t, b.LoadBalancer.Stickiness, "Stickiness")
+				assert.Equal(t, "partition", b.LoadBalancer.Stickiness.CookieName)
+			},
+		},
+		{
+			desc: "Has maxconn amount and extractor func",
+			labels: map[string]string{
+				label.TraefikBackendMaxConnAmount:        "1337",
+				label.TraefikBackendMaxConnExtractorFunc: "request.header.TEST_HEADER",
+			},
+			validate: func(t *testing.T, b *types.Backend) {
+				t.Helper()
+				expected := &types.MaxConn{
+					Amount:        1337,
+					ExtractorFunc: "request.header.TEST_HEADER",
+				}
+				assert.Equal(t, expected, b.MaxConn)
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			provider := Provider{}
+
+			labels := map[string]string{
+				label.TraefikEnable:  "true",
+				traefikSFSecondaries: "read",
+				"traefik.frontend.rule.partition.bce46a8c-b62d-4996-89dc-7ffc00a96902": "Host:stateful.com",
+			}
+			for key, value := range test.labels {
+				labels[key] = value
+			}
+
+			services := []ServiceItemExtended{
+				{
+					ServiceItem: sf.ServiceItem{
+						ID:          "TestApplication/TestService",
+						Name:        "fabric:/TestApplication/TestService",
+						ServiceKind: kindStateful,
+					},
+					Partitions: []PartitionItemExtended{
+						{
+							PartitionItem: sf.PartitionItem{
+								PartitionInformation: sf.PartitionInformation{
+									ID:                   "bce46a8c-b62d-4996-89dc-7ffc00a96902",
+									ServicePartitionKind: partitionKindSingleton,
+								},
+								ServiceKind: kindStateful,
+							},
+							Replicas: []sf.ReplicaItem{
+								newTestReplica("1", "Primary", "http://localhost:8081"),
+								newTestReplica("2", "ActiveSecondary", "http://localhost:8082"),
+							},
+						},
+					},
+					Labels: labels,
+				},
+			}
+
+			config, err := provider.buildConfiguration(services)
+			require.NoError(t, err)
+
+			require.Len(t, config.Backends, 2, "Primary and secondaries backends should be present in the configuration")
+
+			for bname, backend := range config.Backends {
+				require.NotNil(t, backend, "Backend %s is nil", bname)
+				require.NotEmpty(t, backend.Servers, "Backend %s has no servers", bname)
+
+				test.validate(t, backend)
+				if t.Failed() {
+					t.Log(getJSON(backend))
+				}
+			}
+		})
+	}
+}
+
+func TestBuildConfigurationGroupedServicesFrontends(t *testing.T) {
+	services := []ServiceItemExtended{
+		{
+			ServiceItem: sf.ServiceItem{
+				HasPersistedState: true,
+				HealthState:       "Ok",
+				ID:                "TestApplication/TestService",
+				IsServiceGroup:    false,
+				ManifestVersion:   "1.0.0",
+				Name:              "fabric:/TestApplication/TestService",
+				ServiceKind:       kindStateless,
+				ServiceStatus:     "Active",
+				TypeName:          "TestServiceType",
+			},
+			Application: sf.ApplicationItem{
+				HealthState: "Ok",
+				ID:          "TestApplication",
+				Name:        "fabric:/TestApplication",
+				Parameters: []*sf.AppParameter{
+					{
+						Key:   "TraefikPublish",
+						Value: "fabric:/TestApplication/TestService",
+					},
+				},
+				Status:      "Ready",
+				TypeName:    "TestApplicationType",
+				TypeVersion: "1.0.0",
+			},
+			Partitions: []PartitionItemExtended{
+				{
+					PartitionItem: sf.PartitionItem{
+						CurrentConfigurationEpoch: sf.ConfigurationEpoch{
+							ConfigurationVersion: "12884901891",
+							DataLossVersion:      "131496928071680379",
+						},
+						HealthState:       "Ok",
+						MinReplicaSetSize: 1,
+						PartitionInformation: sf.PartitionInformation{
+							HighKey:              "9223372036854775807",
+							ID:                   "bce46a8c-b62d-4996-89dc-7ffc00a96902",
+							LowKey:               "-9223372036854775808",
+							ServicePartitionKind: "Int64Range",
+						},
+						PartitionStatus:      "Ready",
+						ServiceKind:          kindStateless,
+						TargetReplicaSetSize: 1,
+					},
+					Replicas: nil,
+					Instances: []sf.InstanceItem{
+						{
+							ReplicaItemBase: &sf.ReplicaItemBase{
+								Address:                      "{\"Endpoints\":{\"\":\"http://localhost:8081\"}}",
+								HealthState:                  "Ok",
+								LastInBuildDurationInSeconds: "3",
+								NodeName:                     "_Node_0",
+								ReplicaRole:                  "",
+								ReplicaStatus:                "Ready",
+								ServiceKind:                  kindStateless,
+							},
+							ID: "1",
+						},
+					},
+				},
+			},
+			Labels: map[string]string{
+				label.TraefikEnable:  "true",
+				traefikSFGroupName:   "groupedbackends",
+				traefikSFGroupWeight: "154",
+			},
+		},
+	}
+
+	provider := Provider{}
+
+	config, err := provider.buildConfiguration(services)
+	require.NoError(t, err)
+
+	require.NotNil(t, config, "configuration")
+
+	expectedFrontends := map[string]*types.Frontend{
+		"frontend-fabric:/TestApplication/TestService": {
+			Backend:        "fabric:/TestApplication/TestService",
+			PassHostHeader: true,
+		},
+		"groupedbackends": {
+			Backend:        "groupedbackends",
+			PassHostHeader: true,
+			Priority:       50,
+		},
+	}
+
+	assert.Equal(t, expectedFrontends, config.Frontends)
+}
+
+func TestBuildConfigurationGroupedServicesBackends(t *testing.T) {
+	services := []ServiceItemExtended{
+		{
+			ServiceItem: sf.ServiceItem{
+				HasPersistedState: true,
+				HealthState:       "Ok",
+				ID:                "TestApplication/TestService",
+				IsServiceGroup:    false,
+				ManifestVersion:   "1.0.0",
+				Name:              "fabric:/TestApplication/TestService",
+				ServiceKind:       kindStateless,
+				ServiceStatus:     "Active",
+				TypeName:          "TestServiceType",
+			},
+			Application: sf.ApplicationItem{
+				HealthState: "Ok",
+				ID:          "TestApplication",
+				Name:        "fabric:/TestApplication",
+				Parameters: []*sf.AppParameter{
+					{
+						Key:   "TraefikPublish",
+						Value: "fabric:/TestApplication/TestService",
+					},
+				},
+				Status:      "Ready",
+				TypeName:    "TestApplicationType",
+				TypeVersion: "1.0.0",
+			},
+			Partitions: []PartitionItemExtended{
+				{
+					PartitionItem: sf.PartitionItem{
+						CurrentConfigurationEpoch: sf.ConfigurationEpoch{
+							ConfigurationVersion: "12884901891",
+							DataLossVersion:      "131496928071680379",
+						},
+						HealthState:       "Ok",
+						MinReplicaSetSize: 1,
+						PartitionInformation: sf.PartitionInformation{
+							HighKey:              "9223372036854775807",
+							ID:                   "bce46a8c-b62d-4996-89dc-7ffc00a96902",
+							LowKey:               "-9223372036854775808",
+							ServicePartitionKind: "Int64Range",
+						},
+						PartitionStatus:      "Ready",
+						ServiceKind:          kindStateless,
+						TargetReplicaSetSize: 1,
+					},
+					Replicas: nil,
+					Instances: []sf.InstanceItem{
+						{
+							ReplicaItemBase: &sf.ReplicaItemBase{
+								Address:                      "{\"Endpoints\":{\"\":\"http://localhost:8081\"}}",
+								HealthState:                  "Ok",
+								LastInBuildDurationInSeconds: "3",
+								NodeName:                     "_Node_0",
+								ReplicaRole:                  "",
+								ReplicaStatus:                "Ready",
+								ServiceKind:                  kindStateless,
+							},
+							ID: "1",
+						},
+					},
+				},
+			},
+			Labels: map[string]string{
+				label.TraefikEnable:  "true",
+				traefikSFGroupName:   "groupedbackends",
+				traefikSFGroupWeight: "154",
+			},
+		},
+	}
+
+	provider := Provider{}
+
+	config, err := provider.buildConfiguration(services)
+	require.NoError(t, err)
+
+	require.NotNil(t, config, "configuration")
+
+	expected := map[string]*types.Backend{
+		"fabric:/TestApplication/TestService": {
+			Servers: map[string]types.Server{
+				"1": {
+					URL:    "http://localhost:8081",
+					Weight: 1,
+				},
+			},
+		},
+		"groupedbackends": {
+			Servers: map[string]types.Server{
+				"TestApplication/TestService-1": {
+					URL:    "http://localhost:8081",
+					Weight: 154,
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, config.Backends)
+}
+
+func TestBuildConfigurationGroupedServicesOptions(t *testing.T) {
+	services := []ServiceItemExtended{
+		{
+			ServiceItem: sf.ServiceItem{
+				ID:          "TestApplication/TestService",
+				Name:        "fabric:/TestApplication/TestService",
+				ServiceKind: kindStateless,
+			},
+			Partitions: []PartitionItemExtended{
+				{
+					PartitionItem: sf.PartitionItem{
+						PartitionInformation: sf.PartitionInformation{
+							ID: "bce46a8c-b62d-4996-89dc-7ffc00a96902",
+						},
+						ServiceKind: kindStateless,
+					},
+					Instances: []sf.InstanceItem{
+						newTestInstance("1", "http://localhost:8081"),
+					},
+				},
+			},
+			Labels: map[string]string{
+				label.TraefikEnable:                                             "true",
+				traefikSFGroupName:                                              "groupedbackends",
+				"traefik.servicefabric.group.frontend.rule":                     "Host:group.com",
+				"traefik.servicefabric.group.frontend.entryPoints":              "https",
+				"traefik.servicefabric.group.frontend.headers.SSLRedirect":      "true",
+				"traefik.servicefabric.group.backend.loadbalancer.method":       "drr",
+				"traefik.servicefabric.group.backend.circuitbreaker.expression": "NetworkErrorRatio() > 0.5",
+			},
+		},
+		{
+			ServiceItem: sf.ServiceItem{
+				ID:          "TestApplication/OtherService",
+				Name:        "fabric:/TestApplication/OtherService",
+				ServiceKind: kindStateless,
+			},
+			Partitions: []PartitionItemExtended{
+				{
+					PartitionItem: sf.PartitionItem{
+						PartitionInformation: sf.PartitionInformation{
+							ID: "c6a2d1a4-8bd8-4b1a-a1e5-5b4e0bb1d7e1",
+						},
+						ServiceKind: kindStateless,
+					},
+					Instances: []sf.InstanceItem{
+						newTestInstance("2", "http://localhost:8082"),
+					},
+				},
+			},
+			Labels: map[string]string{
+				label.TraefikEnable:                                       "true",
+				label.TraefikFrontendRule:                                 "Host:other.com",
+				traefikSFGroupName:                                        "groupedbackends",
+				"traefik.servicefabric.group.frontend.priority":           "100",
+				"traefik.servicefabric.group.backend.healthcheck.path":    "/health",
+				"traefik.servicefabric.group.backend.loadbalancer.method": "wrr",
+			},
+		},
+	}
+
+	provider := Provider{}
+
+	config, err := provider.buildConfiguration(services)
+	require.NoError(t, err)
+
+	expectedFrontend := &types.Frontend{
+		Backend:        "groupedbackends",
+		EntryPoints:    []string{"https"},
+		PassHostHeader: true,
+		Priority:       100,
+		Routes: map[string]types.Route{
+			label.TraefikFrontendRule: {
+				Rule: "Host:group.com",
+			},
+		},
+		Headers: &types.Headers{
+			SSLRedirect: true,
+		},
+	}
+	assert.Equal(t, expectedFrontend, config.Frontends["groupedbackends"])
+
+	expectedBackend := &types.Backend{
+		Servers: map[string]types.Server{
+			"TestApplication/TestService-1": {
+				URL:    "http://localhost:8081",
+				Weight: 1,
+			},
+			"TestApplication/OtherService-2": {
+				URL:    "http://localhost:8082",
+				Weight: 1,
+			},
+		},
+		CircuitBreaker: &types.CircuitBreaker{
+			Expression: "NetworkErrorRatio() > 0.5",
+		},
+		LoadBalancer: &types.LoadBalancer{
+			Method: "drr",
+		},
+		HealthCheck: &types.HealthCheck{
+			Path: "/health",
+		},
+	}
+	assert.Equal(t, expectedBackend, config.Backends["groupedbackends"])
+}
+
+func TestBuildConfigurationGroupedStatefulServices(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected map[string]types.Server
+	}{
+		{
+			desc: "primaries",
+			labels: map[string]string{
+				label.TraefikEnable:  "true",
+				traefikSFGroupName:   "groupedbackends",
+				traefikSFGroupWeight: "3",
+			},
+			expected: map[string]types.Server{
+				"TestApplication/TestService-1": {
+					URL:    "http://localhost:8081",
+					Weight: 3,
+				},
+				"TestApplication/TestService-4": {
+					URL:    "http://localhost:8084",
+					Weight: 3,
+				},
+			},
+		},
+		{
+			desc: "primaries and secondaries",
+			labels: map[string]string{
+				label.TraefikEnable:       "true",
+				traefikSFGroupName:        "groupedbackends",
+				traefikSFGroupSecondaries: "true",
+			},
+			expected: map[string]types.Server{
+				"TestApplication/TestService-1": {
+					URL:    "http://localhost:8081",
+					Weight: 1,
+				},
+				"TestApplication/TestService-2": {
+					URL:    "http://localhost:8082",
+					Weight: 1,
+				},
+				"TestApplication/TestService-4": {
+					URL:    "http://localhost:8084",
+					Weight: 1,
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			services := []ServiceItemExtended{
+				{
+					ServiceItem: sf.ServiceItem{
+						ID:          "TestApplication/TestService",
+						Name:        "fabric:/TestApplication/TestService",
+						ServiceKind: kindStateful,
+					},
+					Partitions: []PartitionItemExtended{
+						{
+							PartitionItem: sf.PartitionItem{
+								PartitionInformation: sf.PartitionInformation{
+									ID:                   "bce46a8c-b62d-4996-89dc-7ffc00a96902",
+									ServicePartitionKind: partitionKindInt64Range,
+								},
+								ServiceKind: kindStateful,
+							},
+							Replicas: []sf.ReplicaItem{
+								newTestReplica("1", "Primary", "http://localhost:8081"),
+								newTestReplica("2", "ActiveSecondary", "http://localhost:8082"),
+								newTestReplica("3", "IdleSecondary", "http://localhost:8083"),
+							},
+						},
+						{
+							PartitionItem: sf.PartitionItem{
+								PartitionInformation: sf.PartitionInformation{
+									ID:                   "c6a2d1a4-8bd8-4b1a-a1e5-5b4e0bb1d7e1",
+									ServicePartitionKind: partitionKindInt64Range,
+								},
+								ServiceKind: kindStateful,
+							},
+							Replicas: []sf.ReplicaItem{
+								newTestReplica("4", "Primary", "http://localhost:8084"),
+							},
+						},
+					},
+					Labels: test.labels,
+				},
+			}
+
+			provider := Provider{}
+
+			config, err := provider.buildConfiguration(services)
+			require.NoError(t, err)
+
+			require.Contains(t, config.Backends, "groupedbackends")
+			assert.Equal(t, test.expected, config.Backends["groupedbackends"].Servers)
+		})
+	}
+}
+
+func TestBuildConfigurationGroupedStatefulPartitionRules(t *testing.T) {
+	services := []ServiceItemExtended{
+		{
+			ServiceItem: sf.ServiceItem{
+				ID:          "TestApplication/TestService",
+				Name:        "fabric:/TestApplication/TestService",
+				ServiceKind: kindStateful,
+			},
+			Partitions: []PartitionItemExtended{
+				{
+					PartitionItem: sf.PartitionItem{
+						PartitionInformation: sf.PartitionInformation{
+							ID:                   "p1",
+							ServicePartitionKind: partitionKindInt64Range,
+						},
+						ServiceKind: kindStateful,
+					},
+					Replicas: []sf.ReplicaItem{
+						newTestReplica("1", "Primary", "http://localhost:8081"),
+					},
+				},
+				{
+					PartitionItem: sf.PartitionItem{
+						PartitionInformation: sf.PartitionInformation{
+							ID:                   "p2",
+							ServicePartitionKind: partitionKindInt64Range,
+						},
+						ServiceKind: kindStateful,
+					},
+					Replicas: []sf.ReplicaItem{
+						newTestReplica("2", "Primary", "http://localhost:8082"),
+					},
 				},
-			}
-
-			config, err := provider.buildConfiguration(services)
-			require.NoError(t, err)
+			},
+			Labels: map[string]string{
+				label.TraefikEnable:                  "true",
+				traefikSFGroupName:                   "groupedbackends",
+				label.TraefikFrontendRule:            "Host:group.com",
+				"traefik.frontend.rule.partition.p1": "Host:p1.com",
+				"traefik.frontend.rule.partition.p2": "Host:p2.com",
+			},
+		},
+	}
 
-			assert.NotEmpty(t, config.Backends, "No backends present in the configuration")
+	provider := Provider{}
 
-			for bname, backend := range config.Backends {
-				require.NotNil(t, backend, "Backend %s is nil", bname)
+	config, err := provider.buildConfiguration(services)
+	require.NoError(t, err)
 
-				test.validate(t, backend)
-				if t.Failed() {
-					t.Log(getJSON(backend))
-				}
-			}
-		})
+	require.Contains(t, config.Frontends, "groupedbackends")
+	expectedRoutes := map[string]types.Route{
+		label.TraefikFrontendRule: {Rule: "Host:group.com"},
 	}
+	assert.Equal(t, expectedRoutes, config.Frontends["groupedbackends"].Routes)
+
+	require.Contains(t, config.Backends, "groupedbackends")
+	assert.Len(t, config.Backends["groupedbackends"].Servers, 2)
+
+	require.Contains(t, config.Frontends, "fabric:/TestApplication/TestService/p1")
+	assert.Equal(t, map[string]types.Route{
+		"default": {Rule: "Host:p1.com"},
+	}, config.Frontends["fabric:/TestApplication/TestService/p1"].Routes)
 }
 
-func TestBuildConfigurationGroupedServicesFrontends(t *testing.T) {
-	services := []ServiceItemExtended{
-		{
+func TestBuildConfigurationGroupOnlyServices(t *testing.T) {
+	newService := func(name, instanceID, address string, labels map[string]string) ServiceItemExtended {
+		return ServiceItemExtended{
 			ServiceItem: sf.ServiceItem{
-				HasPersistedState: true,
-				HealthState:       "Ok",
-				ID:                "TestApplication/TestService",
-				IsServiceGroup:    false,
-				ManifestVersion:   "1.0.0",
-				Name:              "fabric:/TestApplication/TestService",
-				ServiceKind:       kindStateless,
-				ServiceStatus:     "Active",
-				TypeName:          "TestServiceType",
+				ID:          "TestApplication/" + name,
+				Name:        "fabric:/TestApplication/" + name,
+				ServiceKind: kindStateless,
 			},
-			Application: sf.ApplicationItem{
-				HealthState: "Ok",
-				ID:          "TestApplication",
-				Name:        "fabric:/TestApplication",
-				Parameters: []*sf.AppParameter{
-					{
-						Key:   "TraefikPublish",
-						Value: "fabric:/TestApplication/TestService",
+			Partitions: []PartitionItemExtended{
+				{
+					PartitionItem: sf.PartitionItem{
+						ServiceKind: kindStateless,
+					},
+					Instances: []sf.InstanceItem{
+						newTestInstance(instanceID, address),
 					},
 				},
-				Status:      "Ready",
-				TypeName:    "TestApplicationType",
-				TypeVersion: "1.0.0",
+			},
+			Labels: labels,
+		}
+	}
+
+	services := []ServiceItemExtended{
+		newService("TestService", "1", "http://localhost:8081", map[string]string{
+			label.TraefikEnable:       "true",
+			label.TraefikFrontendRule: "Host:group.com",
+			traefikSFGroupName:        "groupedbackends",
+			traefikSFGroupOnly:        "true",
+		}),
+		newService("DisabledService", "2", "http://localhost:8082", map[string]string{
+			label.TraefikEnable: "false",
+			traefikSFGroupName:  "groupedbackends",
+		}),
+		newService("DisabledGroupService", "3", "http://localhost:8083", map[string]string{
+			traefikSFGroupName: "disabledgroup",
+		}),
+	}
+
+	provider := Provider{}
+
+	config, err := provider.buildConfiguration(services)
+	require.NoError(t, err)
+
+	expectedFrontends := map[string]*types.Frontend{
+		"groupedbackends": {
+			Backend:        "groupedbackends",
+			PassHostHeader: true,
+			Priority:       50,
+			Routes: map[string]types.Route{
+				label.TraefikFrontendRule: {
+					Rule: "Host:group.com",
+				},
+			},
+		},
+	}
+	assert.Equal(t, expectedFrontends, config.Frontends)
+
+	require.Contains(t, config.Backends, "groupedbackends")
+	assert.NotContains(t, config.Backends, "disabledgroup")
+
+	expectedServers := map[string]types.Server{
+		"TestApplication/TestService-1": {
+			URL:    "http://localhost:8081",
+			Weight: 1,
+		},
+	}
+	assert.Equal(t, expectedServers, config.Backends["groupedbackends"].Servers)
+}
+
+func TestBuildConfigurationSegments(t *testing.T) {
+	services := []ServiceItemExtended{
+		{
+			ServiceItem: sf.ServiceItem{
+				ID:          "TestApplication/TestService",
+				Name:        "fabric:/TestApplication/TestService",
+				ServiceKind: kindStateless,
 			},
 			Partitions: []PartitionItemExtended{
 				{
 					PartitionItem: sf.PartitionItem{
-						CurrentConfigurationEpoch: sf.ConfigurationEpoch{
-							ConfigurationVersion: "12884901891",
-							DataLossVersion:      "131496928071680379",
-						},
-						HealthState:       "Ok",
-						MinReplicaSetSize: 1,
-						PartitionInformation: sf.PartitionInformation{
-							HighKey:              "9223372036854775807",
-							ID:                   "bce46a8c-b62d-4996-89dc-7ffc00a96902",
-							LowKey:               "-9223372036854775808",
-							ServicePartitionKind: "Int64Range",
-						},
-						PartitionStatus:      "Ready",
-						ServiceKind:          kindStateless,
-						TargetReplicaSetSize: 1,
+						ServiceKind: kindStateless,
 					},
-					Replicas: nil,
 					Instances: []sf.InstanceItem{
 						{
 							ReplicaItemBase: &sf.ReplicaItemBase{
-								Address:                      "{\"Endpoints\":{\"\":\"http://localhost:8081\"}}",
-								HealthState:                  "Ok",
-								LastInBuildDurationInSeconds: "3",
-								NodeName:                     "_Node_0",
-								ReplicaRole:                  "",
-								ReplicaStatus:                "Ready",
-								ServiceKind:                  kindStateless,
+								Address:       `{"Endpoints":{"api":"http://localhost:8081","admin":"http://localhost:9081"}}`,
+								HealthState:   "Ok",
+								ReplicaStatus: "Ready",
+								ServiceKind:   kindStateless,
 							},
 							ID: "1",
 						},
@@ -855,9 +1941,14 @@ func TestBuildConfigurationGroupedServicesFrontends(t *testing.T) {
 				},
 			},
 			Labels: map[string]string{
-				label.TraefikEnable:  "true",
-				traefikSFGroupName:   "groupedbackends",
-				traefikSFGroupWeight: "154",
+				label.TraefikEnable:                           "true",
+				traefikSFEndpointName:                         "api",
+				"traefik.internal.portName":                   "admin",
+				"traefik.internal.frontend.rule":              "Host:internal.com",
+				"traefik.internal.frontend.entryPoints":       "internal",
+				"traefik.internal.frontend.auth.basic":        "USER1:HASH1",
+				"traefik.public.frontend.rule":                "Host:public.com",
+				"traefik.public.frontend.headers.SSLRedirect": "true",
 			},
 		},
 	}
@@ -867,80 +1958,79 @@ func TestBuildConfigurationGroupedServicesFrontends(t *testing.T) {
 	config, err := provider.buildConfiguration(services)
 	require.NoError(t, err)
 
-	require.NotNil(t, config, "configuration")
-
-	expectedFrontends := map[string]*types.Frontend{
-		"frontend-fabric:/TestApplication/TestService": {
-			Backend:        "fabric:/TestApplication/TestService",
-			PassHostHeader: true,
+	expected := &types.Configuration{
+		Backends: map[string]*types.Backend{
+			"fabric:/TestApplication/TestService/internal": {
+				Servers: map[string]types.Server{
+					"1": {
+						URL:    "http://localhost:9081",
+						Weight: 1,
+					},
+				},
+			},
+			"fabric:/TestApplication/TestService/public": {
+				Servers: map[string]types.Server{
+					"1": {
+						URL:    "http://localhost:8081",
+						Weight: 1,
+					},
+				},
+			},
 		},
-		"groupedbackends": {
-			Backend:  "groupedbackends",
-			Priority: 50,
+		Frontends: map[string]*types.Frontend{
+			"frontend-fabric:/TestApplication/TestService/internal": {
+				Backend:        "fabric:/TestApplication/TestService/internal",
+				EntryPoints:    []string{"internal"},
+				BasicAuth:      []string{"USER1:HASH1"},
+				PassHostHeader: true,
+				Routes: map[string]types.Route{
+					label.TraefikFrontendRule: {
+						Rule: "Host:internal.com",
+					},
+				},
+			},
+			"frontend-fabric:/TestApplication/TestService/public": {
+				Backend:        "fabric:/TestApplication/TestService/public",
+				PassHostHeader: true,
+				Routes: map[string]types.Route{
+					label.TraefikFrontendRule: {
+						Rule: "Host:public.com",
+					},
+				},
+				Headers: &types.Headers{
+					SSLRedirect: true,
+				},
+			},
 		},
 	}
-
-	assert.Equal(t, expectedFrontends, config.Frontends)
+	assert.Equal(t, expected, config)
 }
 
-func TestBuildConfigurationGroupedServicesBackends(t *testing.T) {
+func TestBuildConfigurationEndpointNames(t *testing.T) {
 	services := []ServiceItemExtended{
 		{
 			ServiceItem: sf.ServiceItem{
-				HasPersistedState: true,
-				HealthState:       "Ok",
-				ID:                "TestApplication/TestService",
-				IsServiceGroup:    false,
-				ManifestVersion:   "1.0.0",
-				Name:              "fabric:/TestApplication/TestService",
-				ServiceKind:       kindStateless,
-				ServiceStatus:     "Active",
-				TypeName:          "TestServiceType",
-			},
-			Application: sf.ApplicationItem{
-				HealthState: "Ok",
-				ID:          "TestApplication",
-				Name:        "fabric:/TestApplication",
-				Parameters: []*sf.AppParameter{
-					{
-						Key:   "TraefikPublish",
-						Value: "fabric:/TestApplication/TestService",
-					},
-				},
-				Status:      "Ready",
-				TypeName:    "TestApplicationType",
-				TypeVersion: "1.0.0",
+				ID:          "TestApplication/TestService",
+				Name:        "fabric:/TestApplication/TestService",
+				ServiceKind: kindStateful,
 			},
 			Partitions: []PartitionItemExtended{
 				{
 					PartitionItem: sf.PartitionItem{
-						CurrentConfigurationEpoch: sf.ConfigurationEpoch{
-							ConfigurationVersion: "12884901891",
-							DataLossVersion:      "131496928071680379",
-						},
-						HealthState:       "Ok",
-						MinReplicaSetSize: 1,
 						PartitionInformation: sf.PartitionInformation{
-							HighKey:              "9223372036854775807",
 							ID:                   "bce46a8c-b62d-4996-89dc-7ffc00a96902",
-							LowKey:               "-9223372036854775808",
-							ServicePartitionKind: "Int64Range",
+							ServicePartitionKind: partitionKindSingleton,
 						},
-						PartitionStatus:      "Ready",
-						ServiceKind:          kindStateless,
-						TargetReplicaSetSize: 1,
+						ServiceKind: kindStateful,
 					},
-					Replicas: nil,
-					Instances: []sf.InstanceItem{
+					Replicas: []sf.ReplicaItem{
 						{
 							ReplicaItemBase: &sf.ReplicaItemBase{
-								Address:                      "{\"Endpoints\":{\"\":\"http://localhost:8081\"}}",
-								HealthState:                  "Ok",
-								LastInBuildDurationInSeconds: "3",
-								NodeName:                     "_Node_0",
-								ReplicaRole:                  "",
-								ReplicaStatus:                "Ready",
-								ServiceKind:                  kindStateless,
+								Address:       `{"Endpoints":{"api":"http://localhost:8081","admin":"http://localhost:9081"}}`,
+								HealthState:   "Ok",
+								ReplicaRole:   "Primary",
+								ReplicaStatus: "Ready",
+								ServiceKind:   kindStateful,
 							},
 							ID: "1",
 						},
@@ -948,9 +2038,10 @@ func TestBuildConfigurationGroupedServicesBackends(t *testing.T) {
 				},
 			},
 			Labels: map[string]string{
-				label.TraefikEnable:  "true",
-				traefikSFGroupName:   "groupedbackends",
-				traefikSFGroupWeight: "154",
+				label.TraefikEnable:    "true",
+				traefikSFEndpointNames: "api,admin",
+				"traefik.api.frontend.rule.partition.bce46a8c-b62d-4996-89dc-7ffc00a96902":   "Host:api.com",
+				"traefik.admin.frontend.rule.partition.bce46a8c-b62d-4996-89dc-7ffc00a96902": "Host:admin.com",
 			},
 		},
 	}
@@ -960,27 +2051,53 @@ func TestBuildConfigurationGroupedServicesBackends(t *testing.T) {
 	config, err := provider.buildConfiguration(services)
 	require.NoError(t, err)
 
-	require.NotNil(t, config, "configuration")
-
-	expected := map[string]*types.Backend{
-		"fabric:/TestApplication/TestService": {
-			Servers: map[string]types.Server{
-				"1": {
-					URL:    "http://localhost:8081",
-					Weight: 1,
+	expected := &types.Configuration{
+		Backends: map[string]*types.Backend{
+			"fabric-TestApplication-TestService-adminbce46a8c-b62d-4996-89dc-7ffc00a96902": {
+				LoadBalancer: &types.LoadBalancer{
+					Method: "drr",
+				},
+				Servers: map[string]types.Server{
+					"1": {
+						URL:    "http://localhost:9081",
+						Weight: 1,
+					},
+				},
+			},
+			"fabric-TestApplication-TestService-apibce46a8c-b62d-4996-89dc-7ffc00a96902": {
+				LoadBalancer: &types.LoadBalancer{
+					Method: "drr",
+				},
+				Servers: map[string]types.Server{
+					"1": {
+						URL:    "http://localhost:8081",
+						Weight: 1,
+					},
 				},
 			},
 		},
-		"groupedbackends": {
-			Servers: map[string]types.Server{
-				"TestApplication/TestService-1": {
-					URL:    "http://localhost:8081",
-					Weight: 154,
+		Frontends: map[string]*types.Frontend{
+			"fabric:/TestApplication/TestService/admin/bce46a8c-b62d-4996-89dc-7ffc00a96902": {
+				Backend:        "fabric-TestApplication-TestService-adminbce46a8c-b62d-4996-89dc-7ffc00a96902",
+				PassHostHeader: true,
+				Routes: map[string]types.Route{
+					"default": {
+						Rule: "Host:admin.com",
+					},
+				},
+			},
+			"fabric:/TestApplication/TestService/api/bce46a8c-b62d-4996-89dc-7ffc00a96902": {
+				Backend:        "fabric-TestApplication-TestService-apibce46a8c-b62d-4996-89dc-7ffc00a96902",
+				PassHostHeader: true,
+				Routes: map[string]types.Route{
+					"default": {
+						Rule: "Host:api.com",
+					},
 				},
 			},
 		},
 	}
-	assert.Equal(t, expected, config.Backends)
+	assert.Equal(t, expected, config)
 }
 
 func TestIsPrimary(t *testing.T) {
@@ -1044,3 +2161,46 @@ func getJSON(i interface{}) string {
 	}
 	return string(jsonBytes)
 }
+
+func TestDoesAppParamContain(t *testing.T) {
+	app := sf.ApplicationItem{
+		Name: "fabric:/TestApplication",
+		Parameters: []*sf.AppParameter{
+			nil,
+			{Key: "TraefikPublish", Value: "fabric:/TestApplication/TestService"},
+		},
+	}
+
+	testCases := []struct {
+		desc          string
+		key           string
+		shouldContain string
+		expected      bool
+	}{
+		{
+			desc:          "parameter containing the value",
+			key:           "TraefikPublish",
+			shouldContain: "TestService",
+			expected:      true,
+		},
+		{
+			desc:          "parameter not containing the value",
+			key:           "TraefikPublish",
+			shouldContain: "OtherService",
+		},
+		{
+			desc:          "missing parameter",
+			key:           "Missing",
+			shouldContain: "TestService",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, doesAppParamContain(app, test.key, test.shouldContain))
+		})
+	}
+}
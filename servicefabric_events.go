@@ -0,0 +1,289 @@
+package servicefabric
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/traefik/traefik/log"
+	"github.com/traefik/traefik/types"
+)
+
+const (
+	eventStoreAPIVersion       = "6.4"
+	eventStoreTimeFormat       = "2006-01-02T15:04:05Z"
+	defaultEventPollInterval   = 2 * time.Second
+	defaultEventResyncInterval = 5 * time.Minute
+
+	// eventLookback overlaps the queried time ranges, the EventStore
+	// indexes the events a few seconds after they occurred.
+	eventLookback = 30 * time.Second
+)
+
+// EventStore entities the provider reads the events of. Replica moves
+// are read from the PartitionReconfigured events, the EventStore only
+// lists the replica events of one partition at a time.
+const (
+	eventEntityCluster      = "Cluster"
+	eventEntityNodes        = "Nodes"
+	eventEntityApplications = "Applications"
+	eventEntityServices     = "Services"
+	eventEntityPartitions   = "Partitions"
+)
+
+var eventEntities = []string{eventEntityCluster, eventEntityNodes, eventEntityApplications, eventEntityServices, eventEntityPartitions}
+
+// EventStore holds the settings of the event-driven updates.
+type EventStore struct {
+	PollInterval   flaeg.Duration `description:"Interval between two EventStore queries" export:"true"`
+	ResyncInterval flaeg.Duration `description:"Interval between two full discoveries, catches up on missed events" export:"true"`
+}
+
+func (e *EventStore) intervals() (time.Duration, time.Duration) {
+	pollInterval := time.Duration(e.PollInterval)
+	if pollInterval <= 0 {
+		pollInterval = defaultEventPollInterval
+	}
+
+	resyncInterval := time.Duration(e.ResyncInterval)
+	if resyncInterval <= 0 {
+		resyncInterval = defaultEventResyncInterval
+	}
+	return pollInterval, resyncInterval
+}
+
+// fabricEvent is the subset of an EventStore event used by the provider.
+type fabricEvent struct {
+	Kind            string    `json:"Kind"`
+	EventInstanceID string    `json:"EventInstanceId"`
+	TimeStamp       time.Time `json:"TimeStamp"`
+	PartitionID     string    `json:"PartitionId"`
+}
+
+// eventChanges lists what the events of a poll invalidate.
+type eventChanges struct {
+	partitions map[string]struct{}
+	resync     bool
+}
+
+// eventCursor tracks the events already read from a cluster,
+// each entity is queried from the end of its last successful query.
+type eventCursor struct {
+	queriedUntil map[string]time.Time
+	seen         map[string]time.Time
+}
+
+func newEventCursor(start time.Time) *eventCursor {
+	queriedUntil := make(map[string]time.Time, len(eventEntities))
+	for _, entity := range eventEntities {
+		queriedUntil[entity] = start
+	}
+
+	return &eventCursor{
+		queriedUntil: queriedUntil,
+		seen:         make(map[string]time.Time),
+	}
+}
+
+// poll reads the events which occurred since the previous poll.
+// The entities are queried on their own: the changes of the entities
+// which could be read are returned along with the errors of the others,
+// which are queried again from the same time on the next poll.
+func (c *eventCursor) poll(client sfClient, now time.Time) (eventChanges, error) {
+	changes := eventChanges{partitions: make(map[string]struct{})}
+
+	var errs []error
+	for _, entity := range eventEntities {
+		start := c.queriedUntil[entity].Add(-eventLookback)
+
+		events, err := client.GetEvents(entity, start, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s events: %w", entity, err))
+			continue
+		}
+
+		for _, event := range events {
+			if !c.markSeen(event) {
+				continue
+			}
+
+			switch entity {
+			case eventEntityCluster, eventEntityNodes:
+				// Health reports do not change the topology. The node
+				// statuses are listed again by the full discovery.
+				if !strings.Contains(event.Kind, "Health") {
+					changes.resync = true
+				}
+			case eventEntityApplications:
+				// The upgrade domains to drain follow the upgrade progress.
+				if strings.HasPrefix(event.Kind, "ApplicationUpgrade") {
+					changes.resync = true
+				}
+			case eventEntityServices:
+				if event.Kind == "ServiceCreated" || event.Kind == "ServiceDeleted" {
+					changes.resync = true
+				}
+			case eventEntityPartitions:
+				if event.PartitionID != "" {
+					changes.partitions[event.PartitionID] = struct{}{}
+				}
+			}
+		}
+		c.queriedUntil[entity] = now
+	}
+
+	oldest := now
+	for _, queriedUntil := range c.queriedUntil {
+		if queriedUntil.Before(oldest) {
+			oldest = queriedUntil
+		}
+	}
+	for id, timestamp := range c.seen {
+		if timestamp.Before(oldest.Add(-2 * eventLookback)) {
+			delete(c.seen, id)
+		}
+	}
+	return changes, joinErrors(errs)
+}
+
+// markSeen reports whether the event is read for the first time.
+func (c *eventCursor) markSeen(event fabricEvent) bool {
+	if event.EventInstanceID == "" {
+		return true
+	}
+	if _, exists := c.seen[event.EventInstanceID]; exists {
+		return false
+	}
+	c.seen[event.EventInstanceID] = event.TimeStamp
+	return true
+}
+
+// watchEvents keeps the configuration up to date from the EventStore events,
+// only the services whose partitions changed, e.g. were reconfigured
+// after a replica moved, are rediscovered.
+// All the services are discovered again every ResyncInterval, and when
+// the cluster, its nodes, its services or an application upgrade change.
+func (p *Provider) watchEvents(configurationChan chan<- types.ConfigMessage, stop chan bool, tracker *topologyTracker) error {
+	pollInterval, resyncInterval := p.EventStore.intervals()
+
+	cursors := make([]*eventCursor, len(p.clusters))
+	for i := range cursors {
+		cursors[i] = newEventCursor(time.Now())
+	}
+
+	services, err := p.getServices()
+	if err != nil {
+		return err
+	}
+	if err = p.publish(configurationChan, tracker, services); err != nil {
+		return err
+	}
+
+	pollTicker := time.NewTicker(pollInterval)
+	defer pollTicker.Stop()
+	resyncTicker := time.NewTicker(resyncInterval)
+	defer resyncTicker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-resyncTicker.C:
+			log.Info("Checking service fabric config")
+			services, err = p.getServices()
+		case <-pollTicker.C:
+			services, err = p.applyEvents(cursors, services)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = p.publish(configurationChan, tracker, services); err != nil {
+			return err
+		}
+	}
+}
+
+type partitionKey struct {
+	cluster     string
+	partitionID string
+}
+
+// applyEvents rediscovers the services affected by the events since the
+// previous poll, or all of them when the events cannot be narrowed down.
+// Events of unknown partitions, such as the system services ones, are ignored.
+func (p *Provider) applyEvents(cursors []*eventCursor, services []ServiceItemExtended) ([]ServiceItemExtended, error) {
+	partitionServices := make(map[partitionKey]int)
+	for i, service := range services {
+		for _, partition := range service.Partitions {
+			partitionServices[partitionKey{cluster: service.Cluster, partitionID: partition.PartitionInformation.ID}] = i
+		}
+	}
+
+	now := time.Now()
+	affected := make(map[int]struct{})
+	for i, cluster := range p.clusters {
+		changes, err := cursors[i].poll(cluster.client, now)
+		if err != nil {
+			log.Errorf("Unable to read Service Fabric events%s: %v", clusterLogSuffix(cluster.name), err)
+		}
+
+		if changes.resync {
+			log.Infof("Service Fabric services changed%s, discovering all services", clusterLogSuffix(cluster.name))
+			return p.getServices()
+		}
+
+		for partitionID := range changes.partitions {
+			if index, exists := partitionServices[partitionKey{cluster: cluster.name, partitionID: partitionID}]; exists {
+				affected[index] = struct{}{}
+			}
+		}
+	}
+
+	if len(affected) == 0 {
+		return services, nil
+	}
+	return p.rediscoverServices(services, affected), nil
+}
+
+// rediscoverServices returns a copy of services where the affected ones
+// are discovered again, a service which cannot be rediscovered keeps
+// its previous state.
+func (p *Provider) rediscoverServices(services []ServiceItemExtended, affected map[int]struct{}) []ServiceItemExtended {
+	indexes := make([]int, 0, len(affected))
+	for index := range affected {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	updated := make([]ServiceItemExtended, len(services))
+	copy(updated, services)
+
+	pool := newWorkerPool(p.DiscoveryWorkers)
+	forEach(len(indexes), func(i int) {
+		service := services[indexes[i]]
+		cluster := p.getCluster(service.Cluster)
+		if cluster == nil {
+			return
+		}
+
+		filter := p.getNodeFilter(cluster.client, pool, cluster.nodes, service.Application)
+		var appLabels applicationLabels
+		pool.run(func() {
+			appLabels = getApplicationLabels(cluster.client, &service.Application)
+		})
+
+		item, err := p.getServiceItem(cluster.client, pool, filter, appLabels, service.Application, service.ServiceItem)
+		if err != nil {
+			log.Errorf("Unable to rediscover Service Fabric service %s, keeping its last known state: %v", service.Name, err)
+			return
+		}
+		item.Cluster = service.Cluster
+		updated[indexes[i]] = item
+	})
+
+	log.Debugf("Rediscovered %d Service Fabric services from events", len(indexes))
+	return updated
+}
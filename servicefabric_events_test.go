@@ -0,0 +1,491 @@
+package servicefabric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	sf "github.com/jjcollinge/servicefabric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/provider/label"
+	"github.com/traefik/traefik/safe"
+	"github.com/traefik/traefik/types"
+)
+
+func TestClusterClientGetEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/EventsStore/Partitions/Events" {
+			http.NotFound(rw, req)
+			return
+		}
+
+		query := req.URL.Query()
+		assert.Equal(t, eventStoreAPIVersion, query.Get("api-version"))
+		assert.Equal(t, "2018-04-03T18:00:00Z", query.Get("StartTimeUtc"))
+		assert.Equal(t, "2018-04-03T18:05:00Z", query.Get("EndTimeUtc"))
+
+		fmt.Fprint(rw, `[{
+			"Kind": "PartitionReconfigured",
+			"EventInstanceId": "7b4fd3c2-4e6b-47d9-b5b0-4a4b1a1d6f0a",
+			"TimeStamp": "2018-04-03T18:01:23.6791226Z",
+			"HasCorrelatedEvents": false,
+			"PartitionId": "bce46a8c-b62d-4996-89dc-7ffc00a96902",
+			"NodeName": "_Node_0"
+		}]`)
+	}))
+	defer server.Close()
+
+	client, err := newClusterClient(&http.Client{}, server.URL, "6.0")
+	require.NoError(t, err)
+
+	start := time.Date(2018, 4, 3, 18, 0, 0, 0, time.UTC)
+	events, err := client.GetEvents(eventEntityPartitions, start, start.Add(5*time.Minute))
+	require.NoError(t, err)
+
+	require.Len(t, events, 1)
+	assert.Equal(t, "PartitionReconfigured", events[0].Kind)
+	assert.Equal(t, "7b4fd3c2-4e6b-47d9-b5b0-4a4b1a1d6f0a", events[0].EventInstanceID)
+	assert.Equal(t, "bce46a8c-b62d-4996-89dc-7ffc00a96902", events[0].PartitionID)
+	assert.Equal(t, 2018, events[0].TimeStamp.Year())
+}
+
+func TestEventCursorPoll(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		events             map[string][]fabricEvent
+		expectedPartitions map[string]struct{}
+		expectedResync     bool
+	}{
+		{
+			desc:               "no events",
+			expectedPartitions: map[string]struct{}{},
+		},
+		{
+			desc: "partition events",
+			events: map[string][]fabricEvent{
+				eventEntityPartitions: {
+					{Kind: "PartitionReconfigured", EventInstanceID: "1", PartitionID: "p1"},
+					{Kind: "PartitionPrimaryMoveAnalysis", EventInstanceID: "2", PartitionID: "p2"},
+				},
+			},
+			expectedPartitions: map[string]struct{}{"p1": {}, "p2": {}},
+		},
+		{
+			desc: "cluster health report",
+			events: map[string][]fabricEvent{
+				eventEntityCluster: {
+					{Kind: "ClusterNewHealthReport", EventInstanceID: "1"},
+				},
+			},
+			expectedPartitions: map[string]struct{}{},
+		},
+		{
+			desc: "cluster upgrade",
+			events: map[string][]fabricEvent{
+				eventEntityCluster: {
+					{Kind: "ClusterUpgradeDomainCompleted", EventInstanceID: "1"},
+				},
+			},
+			expectedPartitions: map[string]struct{}{},
+			expectedResync:     true,
+		},
+		{
+			desc: "node health report",
+			events: map[string][]fabricEvent{
+				eventEntityNodes: {
+					{Kind: "NodeNewHealthReport", EventInstanceID: "1"},
+				},
+			},
+			expectedPartitions: map[string]struct{}{},
+		},
+		{
+			desc: "node deactivation",
+			events: map[string][]fabricEvent{
+				eventEntityNodes: {
+					{Kind: "NodeDeactivateStarted", EventInstanceID: "1"},
+				},
+			},
+			expectedPartitions: map[string]struct{}{},
+			expectedResync:     true,
+		},
+		{
+			desc: "application health report",
+			events: map[string][]fabricEvent{
+				eventEntityApplications: {
+					{Kind: "ApplicationNewHealthReport", EventInstanceID: "1"},
+				},
+			},
+			expectedPartitions: map[string]struct{}{},
+		},
+		{
+			desc: "application upgrade domain completed",
+			events: map[string][]fabricEvent{
+				eventEntityApplications: {
+					{Kind: "ApplicationUpgradeDomainCompleted", EventInstanceID: "1"},
+				},
+			},
+			expectedPartitions: map[string]struct{}{},
+			expectedResync:     true,
+		},
+		{
+			desc: "service created",
+			events: map[string][]fabricEvent{
+				eventEntityServices: {
+					{Kind: "ServiceCreated", EventInstanceID: "1"},
+				},
+			},
+			expectedPartitions: map[string]struct{}{},
+			expectedResync:     true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := &clientMock{events: test.events}
+			cursor := newEventCursor(time.Now())
+
+			changes, err := cursor.poll(client, time.Now())
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedPartitions, changes.partitions)
+			assert.Equal(t, test.expectedResync, changes.resync)
+		})
+	}
+}
+
+func TestEventCursorPollSkipsSeenEvents(t *testing.T) {
+	client := &clientMock{
+		events: map[string][]fabricEvent{
+			eventEntityPartitions: {
+				{Kind: "PartitionReconfigured", EventInstanceID: "1", TimeStamp: time.Now(), PartitionID: "p1"},
+			},
+		},
+	}
+	cursor := newEventCursor(time.Now())
+
+	client.eventsError = errors.New("event store unavailable")
+	_, err := cursor.poll(client, time.Now())
+	require.Error(t, err)
+
+	client.eventsError = nil
+	changes, err := cursor.poll(client, time.Now())
+	require.NoError(t, err)
+	assert.Contains(t, changes.partitions, "p1")
+
+	changes, err = cursor.poll(client, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, changes.partitions)
+}
+
+func TestEventCursorPollEntityNotFound(t *testing.T) {
+	var lock sync.Mutex
+	startTimes := make(map[string][]string)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		startTimes[req.URL.Path] = append(startTimes[req.URL.Path], req.URL.Query().Get("StartTimeUtc"))
+		lock.Unlock()
+
+		switch req.URL.Path {
+		case "/EventsStore/Cluster/Events", "/EventsStore/Nodes/Events", "/EventsStore/Applications/Events":
+			fmt.Fprint(rw, `[]`)
+		case "/EventsStore/Partitions/Events":
+			fmt.Fprint(rw, `[{"Kind": "PartitionReconfigured", "EventInstanceId": "1", "TimeStamp": "2018-04-03T18:01:00Z", "PartitionId": "p1"}]`)
+		default:
+			http.NotFound(rw, req)
+		}
+	}))
+	defer server.Close()
+
+	client, err := newClusterClient(&http.Client{}, server.URL, "")
+	require.NoError(t, err)
+
+	start := time.Date(2018, 4, 3, 18, 0, 0, 0, time.UTC)
+	cursor := newEventCursor(start)
+
+	changes, err := cursor.poll(client, start.Add(time.Minute))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Services events")
+	assert.Equal(t, map[string]struct{}{"p1": {}}, changes.partitions)
+	assert.False(t, changes.resync)
+
+	_, err = cursor.poll(client, start.Add(2*time.Minute))
+	require.Error(t, err)
+
+	// The failing entity is queried again from the same time,
+	// the others move on.
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{"2018-04-03T17:59:30Z", "2018-04-03T17:59:30Z"}, startTimes["/EventsStore/Services/Events"])
+	assert.Equal(t, []string{"2018-04-03T17:59:30Z", "2018-04-03T18:00:30Z"}, startTimes["/EventsStore/Partitions/Events"])
+}
+
+func TestApplyEventsRediscoversAffectedServices(t *testing.T) {
+	client := &clientMock{
+		applications: apps,
+		services:     services,
+		partitions:   partitions,
+		instances:    instances,
+		getServiceExtensionMapResult: map[string]string{
+			label.TraefikEnable: "true",
+		},
+	}
+
+	provider := Provider{
+		clusters: []*clusterConnection{{client: client}},
+	}
+
+	serviceItems, err := provider.getServices()
+	require.NoError(t, err)
+	require.Len(t, serviceItems, 1)
+	require.Len(t, serviceItems[0].Partitions[0].Instances, 1)
+
+	cursors := []*eventCursor{newEventCursor(time.Now())}
+
+	client.instances = &sf.InstanceItemsPage{
+		Items: append(append([]sf.InstanceItem{}, instances.Items...), newTestInstance("3", "http://localhost:8083")),
+	}
+	client.events = map[string][]fabricEvent{
+		eventEntityPartitions: {
+			{Kind: "PartitionReconfigured", EventInstanceID: "unknown", PartitionID: "unknown-partition"},
+		},
+	}
+
+	unchanged, err := provider.applyEvents(cursors, serviceItems)
+	require.NoError(t, err)
+	assert.Equal(t, serviceItems, unchanged)
+
+	client.events = map[string][]fabricEvent{
+		eventEntityPartitions: {
+			{Kind: "PartitionReconfigured", EventInstanceID: "known", PartitionID: partitions.Items[0].PartitionInformation.ID},
+		},
+	}
+
+	updated, err := provider.applyEvents(cursors, serviceItems)
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.Len(t, updated[0].Partitions[0].Instances, 2)
+	assert.Len(t, serviceItems[0].Partitions[0].Instances, 1)
+}
+
+func TestApplyEventsNodeDown(t *testing.T) {
+	newInstance := func(id, nodeName string) sf.InstanceItem {
+		instance := newTestInstance(id, "http://localhost:808"+id)
+		instance.NodeName = nodeName
+		return instance
+	}
+
+	client := &clientMock{
+		applications: apps,
+		services:     services,
+		partitions:   partitions,
+		instances: &sf.InstanceItemsPage{
+			Items: []sf.InstanceItem{
+				newInstance("1", "_Node_0"),
+				newInstance("2", "_Node_1"),
+			},
+		},
+		nodes: &nodeItemsPage{
+			Items: []NodeItem{
+				{Name: "_Node_0", NodeStatus: "Up"},
+				{Name: "_Node_1", NodeStatus: "Up"},
+			},
+		},
+		getServiceExtensionMapResult: map[string]string{
+			label.TraefikEnable: "true",
+		},
+	}
+
+	provider := Provider{
+		clusters: []*clusterConnection{{client: client}},
+	}
+
+	serviceItems, err := provider.getServices()
+	require.NoError(t, err)
+	require.Len(t, serviceItems, 1)
+	require.Len(t, serviceItems[0].Partitions[0].Instances, 2)
+
+	cursors := []*eventCursor{newEventCursor(time.Now())}
+
+	client.nodes = &nodeItemsPage{
+		Items: []NodeItem{
+			{Name: "_Node_0", NodeStatus: "Up"},
+			{Name: "_Node_1", NodeStatus: nodeStatusDown},
+		},
+	}
+	client.events = map[string][]fabricEvent{
+		eventEntityNodes: {
+			{Kind: "NodeDown", EventInstanceID: "1"},
+		},
+	}
+
+	updated, err := provider.applyEvents(cursors, serviceItems)
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	require.Len(t, updated[0].Partitions[0].Instances, 1)
+	assert.Equal(t, "1", updated[0].Partitions[0].Instances[0].ID)
+}
+
+func TestApplyEventsApplicationUpgrade(t *testing.T) {
+	newInstance := func(id, nodeName string) sf.InstanceItem {
+		instance := newTestInstance(id, "http://localhost:808"+id)
+		instance.NodeName = nodeName
+		return instance
+	}
+
+	client := &clientMock{
+		applications: apps,
+		services:     services,
+		partitions:   partitions,
+		instances: &sf.InstanceItemsPage{
+			Items: []sf.InstanceItem{
+				newInstance("1", "_Node_0"),
+				newInstance("2", "_Node_1"),
+			},
+		},
+		nodes: &nodeItemsPage{
+			Items: []NodeItem{
+				{Name: "_Node_0", NodeStatus: "Up", UpgradeDomain: "0"},
+				{Name: "_Node_1", NodeStatus: "Up", UpgradeDomain: "1"},
+			},
+		},
+		upgradeProgress: map[string]*applicationUpgradeProgress{
+			apps.Items[0].ID: newUpgradeProgress(upgradeStateRollingForwardInProgress, "1"),
+		},
+		getServiceExtensionMapResult: map[string]string{
+			label.TraefikEnable: "true",
+		},
+	}
+
+	provider := Provider{
+		DrainUpgradeDomains: true,
+		clusters:            []*clusterConnection{{client: client}},
+	}
+
+	serviceItems, err := provider.getServices()
+	require.NoError(t, err)
+	require.Len(t, serviceItems, 1)
+	require.Len(t, serviceItems[0].Partitions[0].Instances, 2)
+
+	cursors := []*eventCursor{newEventCursor(time.Now())}
+
+	upgradingApp := apps.Items[0]
+	upgradingApp.Status = applicationStatusUpgrading
+	client.applications = &sf.ApplicationItemsPage{Items: []sf.ApplicationItem{upgradingApp}}
+	client.events = map[string][]fabricEvent{
+		eventEntityApplications: {
+			{Kind: "ApplicationUpgradeStarted", EventInstanceID: "1"},
+		},
+	}
+
+	updated, err := provider.applyEvents(cursors, serviceItems)
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.Equal(t, applicationStatusUpgrading, updated[0].Application.Status)
+	require.Len(t, updated[0].Partitions[0].Instances, 1)
+	assert.Equal(t, "1", updated[0].Partitions[0].Instances[0].ID)
+}
+
+func TestUpdateConfigEventStore(t *testing.T) {
+	client := &eventsClientMock{
+		clientMock: &clientMock{
+			applications: apps,
+			services:     services,
+			partitions:   partitions,
+			instances:    instances,
+			getServiceExtensionMapResult: map[string]string{
+				label.TraefikEnable: "true",
+			},
+		},
+	}
+
+	provider := Provider{
+		EventStore: &EventStore{
+			PollInterval:   flaeg.Duration(10 * time.Millisecond),
+			ResyncInterval: flaeg.Duration(time.Hour),
+		},
+		clusters: []*clusterConnection{{client: client}},
+	}
+
+	configurationChan := make(chan types.ConfigMessage, 10)
+	pool := safe.NewPool(context.Background())
+	defer pool.Stop()
+
+	err := provider.updateConfig(configurationChan, pool, time.Hour)
+	require.NoError(t, err)
+
+	select {
+	case <-configurationChan:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Provider failed to return configuration")
+	}
+
+	client.update(
+		&sf.InstanceItemsPage{Items: []sf.InstanceItem{newTestInstance("3", "http://localhost:8083")}},
+		map[string][]fabricEvent{
+			eventEntityPartitions: {
+				{Kind: "PartitionReconfigured", EventInstanceID: "1", PartitionID: partitions.Items[0].PartitionInformation.ID},
+			},
+		},
+	)
+
+	select {
+	case message := <-configurationChan:
+		backend := message.Configuration.Backends["fabric:/TestApplication/TestService"]
+		require.NotNil(t, backend)
+		assert.Equal(t, "http://localhost:8083", backend.Servers["3"].URL)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Provider failed to apply the events")
+	}
+}
+
+func newTestInstance(id, address string) sf.InstanceItem {
+	return sf.InstanceItem{
+		ReplicaItemBase: &sf.ReplicaItemBase{
+			Address:       `{"Endpoints":{"":"` + address + `"}}`,
+			HealthState:   "Ok",
+			ReplicaStatus: "Ready",
+			ServiceKind:   kindStateless,
+		},
+		ID: id,
+	}
+}
+
+// eventsClientMock lets a test change the instances and
+// the events while the provider is polling.
+type eventsClientMock struct {
+	*clientMock
+	lock sync.Mutex
+}
+
+func (c *eventsClientMock) update(instances *sf.InstanceItemsPage, events map[string][]fabricEvent) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.clientMock.instances = instances
+	c.clientMock.events = events
+}
+
+func (c *eventsClientMock) GetInstances(appName, serviceName, partitionName, continuationToken string) (*sf.InstanceItemsPage, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.clientMock.GetInstances(appName, serviceName, partitionName, continuationToken)
+}
+
+func (c *eventsClientMock) GetEvents(entity string, start, end time.Time) ([]fabricEvent, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.clientMock.GetEvents(entity, start, end)
+}
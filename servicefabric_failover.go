@@ -0,0 +1,121 @@
+package servicefabric
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/traefik/traefik/log"
+)
+
+// failoverTransport sends requests to the active management endpoint
+// and rotates through the others on connection errors.
+// Requests are built against the first endpoint.
+type failoverTransport struct {
+	base      http.RoundTripper
+	endpoints []*url.URL
+
+	lock   sync.Mutex
+	active int
+}
+
+func newFailoverTransport(base http.RoundTripper, endpoints []*url.URL) *failoverTransport {
+	return &failoverTransport{
+		base:      base,
+		endpoints: endpoints,
+	}
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := t.activeIndex()
+
+	// A consumed body can't be sent again.
+	attempts := len(t.endpoints)
+	if req.Body != nil && req.Body != http.NoBody {
+		attempts = 1
+	}
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		index := (start + i) % len(t.endpoints)
+		endpoint := t.endpoints[index]
+
+		resp, err := t.base.RoundTrip(t.rewrite(req, endpoint))
+		if err == nil {
+			t.setActive(index)
+			return resp, nil
+		}
+
+		lastErr = err
+		if req.Context().Err() != nil {
+			break
+		}
+		log.Warnf("Service Fabric management endpoint %s failed: %v", endpoint, err)
+	}
+
+	if attempts > 1 {
+		return nil, fmt.Errorf("all %d management endpoints failed, last error: %w", attempts, lastErr)
+	}
+	return nil, lastErr
+}
+
+// rewrite sends req to endpoint, keeping the path below the first endpoint.
+func (t *failoverTransport) rewrite(req *http.Request, endpoint *url.URL) *http.Request {
+	endpointReq := req.Clone(req.Context())
+	endpointReq.URL.Scheme = endpoint.Scheme
+	endpointReq.URL.Host = endpoint.Host
+	endpointReq.URL.Path = endpoint.Path + strings.TrimPrefix(req.URL.Path, t.endpoints[0].Path)
+	endpointReq.URL.RawPath = ""
+	endpointReq.Host = endpoint.Host
+	return endpointReq
+}
+
+func (t *failoverTransport) activeIndex() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.active
+}
+
+func (t *failoverTransport) setActive(index int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.active != index {
+		log.Infof("Switched active Service Fabric management endpoint from %s to %s", t.endpoints[t.active], t.endpoints[index])
+		t.active = index
+	}
+}
+
+// activeEndpoint returns the endpoint which answered last.
+func (t *failoverTransport) activeEndpoint() *url.URL {
+	return t.endpoints[t.activeIndex()]
+}
+
+// parseEndpoints parses a comma separated list of management endpoints.
+func parseEndpoints(value string) ([]*url.URL, error) {
+	var endpoints []*url.URL
+	for _, raw := range strings.Split(value, ",") {
+		raw = strings.TrimSuffix(strings.TrimSpace(raw), "/")
+		if raw == "" {
+			continue
+		}
+
+		endpoint, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid management endpoint %q: %w", raw, err)
+		}
+		if endpoint.Scheme == "" || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid management endpoint %q: scheme and host are required", raw)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	if len(endpoints) == 0 {
+		return nil, errors.New("no management endpoint configured")
+	}
+	return endpoints, nil
+}
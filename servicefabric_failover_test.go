@@ -0,0 +1,152 @@
+package servicefabric
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailoverTransport(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+
+	var hits int32
+	up := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		assert.Equal(t, "/Applications/", req.URL.Path)
+		fmt.Fprint(rw, `{"Items":[{"Id":"app1"}]}`)
+	}))
+	defer up.Close()
+
+	endpoints, err := parseEndpoints(downURL + ", " + up.URL)
+	require.NoError(t, err)
+
+	failover := newFailoverTransport(newTLSTransport(nil), endpoints)
+	client, err := newClusterClient(&http.Client{Transport: failover}, endpoints[0].String(), "")
+	require.NoError(t, err)
+
+	apps, err := listApplications(client)
+	require.NoError(t, err)
+	require.Len(t, apps, 1)
+	assert.Equal(t, up.URL, failover.activeEndpoint().String())
+
+	// The healthy endpoint is remembered.
+	_, err = listApplications(client)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+	assert.Equal(t, up.URL, failover.activeEndpoint().String())
+}
+
+func TestFailoverTransportAllEndpointsDown(t *testing.T) {
+	first := httptest.NewServer(http.NotFoundHandler())
+	second := httptest.NewServer(http.NotFoundHandler())
+	endpoints, err := parseEndpoints(first.URL + "," + second.URL)
+	require.NoError(t, err)
+	first.Close()
+	second.Close()
+
+	failover := newFailoverTransport(newTLSTransport(nil), endpoints)
+	client, err := newClusterClient(&http.Client{Transport: failover}, endpoints[0].String(), "")
+	require.NoError(t, err)
+
+	_, err = listApplications(client)
+	require.Error(t, err)
+	assert.Equal(t, first.URL, failover.activeEndpoint().String())
+}
+
+func TestFailoverTransportKeepsPathPrefix(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		paths = append(paths, req.URL.Path)
+		fmt.Fprint(rw, `{"Items":[]}`)
+	}))
+	defer server.Close()
+
+	endpoints, err := parseEndpoints(server.URL + "/primary," + server.URL + "/secondary")
+	require.NoError(t, err)
+
+	failover := newFailoverTransport(newTLSTransport(nil), endpoints)
+	failover.active = 1
+
+	client, err := newClusterClient(&http.Client{Transport: failover}, endpoints[0].String(), "")
+	require.NoError(t, err)
+
+	_, err = listApplications(client)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/secondary/Applications/"}, paths)
+}
+
+func TestParseEndpoints(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		value         string
+		expected      []string
+		errorExpected bool
+	}{
+		{
+			desc:     "single endpoint",
+			value:    "http://localhost:19080",
+			expected: []string{"http://localhost:19080"},
+		},
+		{
+			desc:     "several endpoints",
+			value:    "https://node0:19080/, https://node1:19080 ,,https://node2:19080",
+			expected: []string{"https://node0:19080", "https://node1:19080", "https://node2:19080"},
+		},
+		{
+			desc:          "empty",
+			value:         " , ",
+			errorExpected: true,
+		},
+		{
+			desc:          "missing scheme",
+			value:         "localhost:19080",
+			errorExpected: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			endpoints, err := parseEndpoints(test.value)
+			if test.errorExpected {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var actual []string
+			for _, endpoint := range endpoints {
+				actual = append(actual, endpoint.String())
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestFailoverTransportRewrite(t *testing.T) {
+	endpoints, err := parseEndpoints("http://node0:19080,https://node1:19080")
+	require.NoError(t, err)
+
+	failover := newFailoverTransport(newTLSTransport(nil), endpoints)
+
+	req, err := http.NewRequest(http.MethodGet, "http://node0:19080/Applications/?api-version=3.0", nil)
+	require.NoError(t, err)
+
+	rewritten := failover.rewrite(req, endpoints[1])
+
+	expected, err := url.Parse("https://node1:19080/Applications/?api-version=3.0")
+	require.NoError(t, err)
+	assert.Equal(t, expected.String(), rewritten.URL.String())
+	assert.Equal(t, "node1:19080", rewritten.Host)
+	assert.Equal(t, "node0:19080", req.Host, "original request must not change")
+}
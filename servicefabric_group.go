@@ -0,0 +1,140 @@
+package servicefabric
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/traefik/traefik/log"
+	"github.com/traefik/traefik/provider/label"
+)
+
+const defaultGroupPriority = 50
+
+// serviceGroup is a backend and frontend shared by the services
+// with the same traefik.servicefabric.groupname label.
+type serviceGroup struct {
+	Name string
+	// Service carries the group level labels, it configures
+	// the options of the group frontend and backend.
+	Service ServiceItemExtended
+	Members []ServiceItemExtended
+}
+
+// getServiceGroups returns the groups of the enabled services sorted by name,
+// a group without enabled members is dropped.
+func getServiceGroups(services []ServiceItemExtended) []serviceGroup {
+	var enabledServices []ServiceItemExtended
+	for _, service := range services {
+		if label.GetBoolValue(service.Labels, label.TraefikEnable, false) {
+			enabledServices = append(enabledServices, service)
+		}
+	}
+
+	groupedServices := getServices(enabledServices, traefikSFGroupName)
+
+	groups := make([]serviceGroup, 0, len(groupedServices))
+	for name, members := range groupedServices {
+		groups = append(groups, serviceGroup{
+			Name:    name,
+			Service: getGroupService(name, members),
+			Members: members,
+		})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// getGroupedServices returns the members of the service groups keyed by
+// group name, custom templates used it before the groups were exposed
+// as .Groups.
+func getGroupedServices(services []ServiceItemExtended) map[string][]ServiceItemExtended {
+	groupedServices := make(map[string][]ServiceItemExtended)
+	for _, group := range getServiceGroups(services) {
+		groupedServices[group.Name] = group.Members
+	}
+	return groupedServices
+}
+
+// getGroupService resolves the group level labels from the
+// traefik.servicefabric.group.* labels of the members, e.g.
+// traefik.servicefabric.group.frontend.entryPoints is the
+// traefik.frontend.entryPoints label of the group.
+// The first member setting a label wins, the conflicting values are logged.
+// The group uses the frontend rules of its first member when none is set,
+// except the partition rules.
+func getGroupService(groupName string, members []ServiceItemExtended) ServiceItemExtended {
+	labels := make(map[string]string)
+	owners := make(map[string]string)
+
+	for _, member := range members {
+		for key, value := range getServiceLabelsWithPrefix(member, traefikSFGroupLabelPrefix) {
+			groupKey := label.Prefix + strings.TrimPrefix(key, traefikSFGroupLabelPrefix)
+
+			if current, exists := labels[groupKey]; exists {
+				if current != value {
+					log.Warnf("Conflicting values for label %s in service group %s: keeping %q from service %s, ignoring %q from service %s",
+						key, groupName, current, owners[groupKey], value, member.Name)
+				}
+				continue
+			}
+
+			labels[groupKey] = value
+			owners[groupKey] = member.Name
+		}
+	}
+
+	group := ServiceItemExtended{Labels: labels}
+	group.Name = groupName
+
+	if len(members) > 0 && len(getServiceLabelsWithPrefix(group, label.TraefikFrontendRule)) == 0 {
+		rules := getGroupMemberRules(members[0])
+		for _, member := range members[1:] {
+			if !reflect.DeepEqual(rules, getGroupMemberRules(member)) {
+				log.Warnf("Conflicting frontend rules in service group %s: keeping the rules of service %s, ignoring the rules of service %s",
+					groupName, members[0].Name, member.Name)
+			}
+		}
+
+		for key, value := range rules {
+			labels[key] = value
+		}
+	}
+	return group
+}
+
+// getGroupMemberRules returns the frontend rules of a member usable by its
+// group. The partition rules are left out: the routes of a frontend are
+// all required, a group would not match any request with them.
+func getGroupMemberRules(member ServiceItemExtended) map[string]string {
+	rules := getServiceLabelsWithPrefix(member, label.TraefikFrontendRule)
+	for key := range rules {
+		if strings.HasPrefix(key, traefikFrontendPartitionRulePrefix) {
+			delete(rules, key)
+		}
+	}
+	return rules
+}
+
+// isGroupOnly reports whether the service is only reachable through
+// its service group, its own frontends are not generated.
+func isGroupOnly(service ServiceItemExtended) bool {
+	return label.Has(service.Labels, traefikSFGroupName) && label.GetBoolValue(service.Labels, traefikSFGroupOnly, false)
+}
+
+func getGroupPriority(group serviceGroup) int {
+	return label.GetIntValue(group.Service.Labels, label.TraefikFrontendPriority, defaultGroupPriority)
+}
+
+// isGroupReplica reports whether the stateful replica is a server of
+// the service group: the primary replicas always are, the active
+// secondary ones when the traefik.servicefabric.groupsecondaries label is set.
+func isGroupReplica(service ServiceItemExtended, replica replicaInstance) bool {
+	if isPrimary(replica) {
+		return true
+	}
+	return isActiveSecondary(replica) && label.GetBoolValue(service.Labels, traefikSFGroupSecondaries, false)
+}
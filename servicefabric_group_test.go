@@ -0,0 +1,188 @@
+package servicefabric
+
+import (
+	"testing"
+
+	sf "github.com/jjcollinge/servicefabric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/provider/label"
+)
+
+func TestGetGroupService(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		members  []map[string]string
+		expected map[string]string
+	}{
+		{
+			desc: "without group labels",
+			members: []map[string]string{
+				{traefikSFGroupName: "group"},
+			},
+			expected: map[string]string{},
+		},
+		{
+			desc: "group labels",
+			members: []map[string]string{
+				{
+					traefikSFGroupName:   "group",
+					traefikSFGroupWeight: "2",
+					"traefik.servicefabric.group.frontend.entryPoints":     "https",
+					"traefik.servicefabric.group.backend.healthcheck.path": "/health",
+				},
+				{
+					traefikSFGroupName: "group",
+					"traefik.servicefabric.group.frontend.priority": "10",
+				},
+			},
+			expected: map[string]string{
+				label.TraefikFrontendEntryPoints:    "https",
+				label.TraefikBackendHealthCheckPath: "/health",
+				label.TraefikFrontendPriority:       "10",
+			},
+		},
+		{
+			desc: "conflicting group labels",
+			members: []map[string]string{
+				{
+					traefikSFGroupName: "group",
+					"traefik.servicefabric.group.frontend.priority": "10",
+				},
+				{
+					traefikSFGroupName: "group",
+					"traefik.servicefabric.group.frontend.priority": "20",
+				},
+			},
+			expected: map[string]string{
+				label.TraefikFrontendPriority: "10",
+			},
+		},
+		{
+			desc: "rules of the first member",
+			members: []map[string]string{
+				{
+					traefikSFGroupName:        "group",
+					label.TraefikFrontendRule: "Host:first.com",
+				},
+				{
+					traefikSFGroupName:        "group",
+					label.TraefikFrontendRule: "Host:second.com",
+				},
+			},
+			expected: map[string]string{
+				label.TraefikFrontendRule: "Host:first.com",
+			},
+		},
+		{
+			desc: "group rule",
+			members: []map[string]string{
+				{
+					traefikSFGroupName:        "group",
+					label.TraefikFrontendRule: "Host:first.com",
+				},
+				{
+					traefikSFGroupName:                          "group",
+					"traefik.servicefabric.group.frontend.rule": "Host:group.com",
+				},
+			},
+			expected: map[string]string{
+				label.TraefikFrontendRule: "Host:group.com",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var members []ServiceItemExtended
+			for _, labels := range test.members {
+				members = append(members, ServiceItemExtended{
+					ServiceItem: sf.ServiceItem{Name: "fabric:/TestApplication/TestService"},
+					Labels:      labels,
+				})
+			}
+
+			group := getGroupService("group", members)
+			assert.Equal(t, "group", group.Name)
+			assert.Equal(t, test.expected, group.Labels)
+		})
+	}
+}
+
+func TestGetServiceGroups(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		services []map[string]string
+		expected map[string]int
+	}{
+		{
+			desc: "sorted groups",
+			services: []map[string]string{
+				{label.TraefikEnable: "true", traefikSFGroupName: "b"},
+				{label.TraefikEnable: "true"},
+				{label.TraefikEnable: "true", traefikSFGroupName: "a"},
+				{label.TraefikEnable: "true", traefikSFGroupName: "b"},
+			},
+			expected: map[string]int{"a": 1, "b": 2},
+		},
+		{
+			desc: "disabled members",
+			services: []map[string]string{
+				{label.TraefikEnable: "true", traefikSFGroupName: "a"},
+				{label.TraefikEnable: "false", traefikSFGroupName: "a"},
+				{traefikSFGroupName: "a"},
+			},
+			expected: map[string]int{"a": 1},
+		},
+		{
+			desc: "without enabled members",
+			services: []map[string]string{
+				{label.TraefikEnable: "false", traefikSFGroupName: "a"},
+				{label.TraefikEnable: "true", traefikSFGroupName: "b"},
+			},
+			expected: map[string]int{"b": 1},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var services []ServiceItemExtended
+			for _, labels := range test.services {
+				services = append(services, ServiceItemExtended{Labels: labels})
+			}
+
+			groups := getServiceGroups(services)
+
+			members := make(map[string]int)
+			for i, group := range groups {
+				if i > 0 {
+					assert.True(t, groups[i-1].Name < group.Name, "groups are not sorted")
+				}
+				members[group.Name] = len(group.Members)
+			}
+			assert.Equal(t, test.expected, members)
+		})
+	}
+}
+
+func TestGetGroupedServices(t *testing.T) {
+	services := []ServiceItemExtended{
+		{ServiceItem: sf.ServiceItem{Name: "fabric:/App/A"}, Labels: map[string]string{label.TraefikEnable: "true", traefikSFGroupName: "a"}},
+		{ServiceItem: sf.ServiceItem{Name: "fabric:/App/B"}, Labels: map[string]string{label.TraefikEnable: "true", traefikSFGroupName: "a"}},
+		{ServiceItem: sf.ServiceItem{Name: "fabric:/App/C"}, Labels: map[string]string{label.TraefikEnable: "true"}},
+		{ServiceItem: sf.ServiceItem{Name: "fabric:/App/D"}, Labels: map[string]string{label.TraefikEnable: "false", traefikSFGroupName: "b"}},
+	}
+
+	groupedServices := getGroupedServices(services)
+
+	require.Len(t, groupedServices, 1)
+	require.Len(t, groupedServices["a"], 2)
+	assert.Equal(t, "fabric:/App/A", groupedServices["a"][0].Name)
+	assert.Equal(t, "fabric:/App/B", groupedServices["a"][1].Name)
+}
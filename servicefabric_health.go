@@ -0,0 +1,76 @@
+package servicefabric
+
+import (
+	"strings"
+
+	sf "github.com/jjcollinge/servicefabric"
+	"github.com/traefik/traefik/provider/label"
+)
+
+var (
+	defaultRoutableReplicaStatuses = []string{"Ready"}
+	defaultExcludedHealthStates    = []string{"Error"}
+)
+
+// HealthPolicy holds the replica statuses and health states routed to.
+// A nil field uses its default, so the replicas in Error health are
+// excluded unless ExcludedHealthStates is set, an empty list routes
+// every health state.
+type HealthPolicy struct {
+	ReplicaStatuses      []string `description:"Replica statuses routed to, Ready by default" export:"true"`
+	ExcludedHealthStates []string `description:"Health states never routed to, Error by default" export:"true"`
+}
+
+// getHealthPolicy returns the health policy of a service, the
+// traefik.servicefabric.health.* labels override the provider settings.
+func (p *Provider) getHealthPolicy(labels map[string]string) *HealthPolicy {
+	policy := &HealthPolicy{
+		ReplicaStatuses:      defaultRoutableReplicaStatuses,
+		ExcludedHealthStates: defaultExcludedHealthStates,
+	}
+
+	if p.HealthPolicy != nil {
+		if len(p.HealthPolicy.ReplicaStatuses) > 0 {
+			policy.ReplicaStatuses = p.HealthPolicy.ReplicaStatuses
+		}
+		if p.HealthPolicy.ExcludedHealthStates != nil {
+			policy.ExcludedHealthStates = p.HealthPolicy.ExcludedHealthStates
+		}
+	}
+
+	if statuses := label.GetSliceStringValue(labels, traefikSFHealthReplicaStatuses); len(statuses) > 0 {
+		policy.ReplicaStatuses = statuses
+	}
+	if label.Has(labels, traefikSFHealthExcludedHealthStates) {
+		policy.ExcludedHealthStates = label.GetSliceStringValue(labels, traefikSFHealthExcludedHealthStates)
+	}
+	return policy
+}
+
+// isHealthy reports whether the replica can be routed to,
+// the defaults are used when policy or its fields are nil.
+func isHealthy(instanceData *sf.ReplicaItemBase, policy *HealthPolicy) bool {
+	if instanceData == nil {
+		return false
+	}
+
+	statuses, excludedStates := defaultRoutableReplicaStatuses, defaultExcludedHealthStates
+	if policy != nil {
+		if len(policy.ReplicaStatuses) > 0 {
+			statuses = policy.ReplicaStatuses
+		}
+		if policy.ExcludedHealthStates != nil {
+			excludedStates = policy.ExcludedHealthStates
+		}
+	}
+	return containsFold(statuses, instanceData.ReplicaStatus) && !containsFold(excludedStates, instanceData.HealthState)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -8,10 +8,29 @@ import (
 
 // SF Specific Traefik Labels.
 const (
+	traefikSFPrefix                      = "traefik.servicefabric."
+	traefikSFEndpointName                = "traefik.servicefabric.endpointname"
+	traefikSFEndpointNames               = "traefik.servicefabric.endpointnames"
 	traefikSFGroupName                   = "traefik.servicefabric.groupname"
 	traefikSFGroupWeight                 = "traefik.servicefabric.groupweight"
+	traefikSFGroupLabelPrefix            = "traefik.servicefabric.group."
+	traefikSFGroupSecondaries            = "traefik.servicefabric.groupsecondaries"
+	traefikSFGroupOnly                   = "traefik.servicefabric.grouponly"
 	traefikSFEnableLabelOverrides        = "traefik.servicefabric.enablelabeloverrides"
 	traefikSFEnableLabelOverridesDefault = true
+	traefikSFSecondaries                 = "traefik.servicefabric.secondaries"
+	traefikSFSecondariesRule             = "traefik.servicefabric.secondaries.rule"
+	traefikSFPartitionKeyHeader          = "traefik.servicefabric.partitionkey.header"
+	traefikSFPartitionKeyQuery           = "traefik.servicefabric.partitionkey.query"
+	traefikSFPartitionKeyPath            = "traefik.servicefabric.partitionkey.path"
+	traefikSFHealthReplicaStatuses       = "traefik.servicefabric.health.replicastatuses"
+	traefikSFHealthExcludedHealthStates  = "traefik.servicefabric.health.excludedhealthstates"
+)
+
+// Values of the traefik.servicefabric.secondaries label.
+const (
+	secondariesRead        = "read"
+	defaultSecondariesRule = "Method:GET,HEAD"
 )
 
 func getFuncBoolLabel(labelName string, defaultValue bool) func(service ServiceItemExtended) bool {
@@ -58,12 +77,6 @@ func getFuncServiceLabelWithPrefix(labelName string) func(service ServiceItemExt
 	}
 }
 
-func getFuncServicesGroupedByLabel(labelName string) func(services []ServiceItemExtended) map[string][]ServiceItemExtended {
-	return func(services []ServiceItemExtended) map[string][]ServiceItemExtended {
-		return getServices(services, labelName)
-	}
-}
-
 func getServiceLabelsWithPrefix(service ServiceItemExtended, prefix string) map[string]string {
 	results := make(map[string]string)
 	for k, v := range service.Labels {
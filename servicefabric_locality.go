@@ -0,0 +1,42 @@
+package servicefabric
+
+import "strings"
+
+const defaultLocalFaultDomainWeight = 10
+
+// getReplicaNode returns the node hosting the replica, when known.
+func getReplicaNode(partition PartitionItemExtended, instance replicaInstance) (NodeItem, bool) {
+	_, data := instance.GetReplicaData()
+	node, exists := partition.Nodes[data.NodeName]
+	return node, exists
+}
+
+// getFaultDomain returns the fault domain of the node hosting the replica.
+func getFaultDomain(partition PartitionItemExtended, instance replicaInstance) string {
+	node, _ := getReplicaNode(partition, instance)
+	return node.FaultDomain
+}
+
+// isLocalFaultDomain reports whether the fault domain is the local one
+// or one of its children, e.g. fd:/dc1/rack1 is in fd:/dc1.
+func (p *Provider) isLocalFaultDomain(faultDomain string) bool {
+	local := strings.TrimSuffix(p.LocalFaultDomain, "/")
+	if local == "" || faultDomain == "" {
+		return false
+	}
+	return faultDomain == local || strings.HasPrefix(faultDomain, local+"/")
+}
+
+// getLocalityWeight multiplies the weight of a server in the local fault
+// domain, the other servers keep their weight as a fallback.
+func (p *Provider) getLocalityWeight(partition PartitionItemExtended, instance replicaInstance, weight int) int {
+	if !p.isLocalFaultDomain(getFaultDomain(partition, instance)) {
+		return weight
+	}
+
+	factor := p.LocalFaultDomainWeight
+	if factor <= 0 {
+		factor = defaultLocalFaultDomainWeight
+	}
+	return weight * factor
+}
@@ -0,0 +1,180 @@
+package servicefabric
+
+import (
+	"testing"
+
+	sf "github.com/jjcollinge/servicefabric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/provider/label"
+)
+
+func TestIsLocalFaultDomain(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		localFaultDomain string
+		faultDomain      string
+		expected         bool
+	}{
+		{
+			desc:             "same fault domain",
+			localFaultDomain: "fd:/dc1/rack1",
+			faultDomain:      "fd:/dc1/rack1",
+			expected:         true,
+		},
+		{
+			desc:             "child fault domain",
+			localFaultDomain: "fd:/dc1/",
+			faultDomain:      "fd:/dc1/rack1",
+			expected:         true,
+		},
+		{
+			desc:             "other fault domain",
+			localFaultDomain: "fd:/dc1",
+			faultDomain:      "fd:/dc10/rack1",
+		},
+		{
+			desc:        "without local fault domain",
+			faultDomain: "fd:/dc1/rack1",
+		},
+		{
+			desc:             "unknown fault domain",
+			localFaultDomain: "fd:/dc1",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			provider := Provider{LocalFaultDomain: test.localFaultDomain}
+			assert.Equal(t, test.expected, provider.isLocalFaultDomain(test.faultDomain))
+		})
+	}
+}
+
+func TestGetNodeWeightLocality(t *testing.T) {
+	partition := PartitionItemExtended{
+		Nodes: map[string]NodeItem{
+			"local":           {Name: "local", NodeStatus: "Up", FaultDomain: "fd:/dc1/rack1", UpgradeDomain: "0"},
+			"remote":          {Name: "remote", NodeStatus: "Up", FaultDomain: "fd:/dc2/rack1", UpgradeDomain: "1"},
+			"local-disabling": {Name: "local-disabling", NodeStatus: nodeStatusDisabling, FaultDomain: "fd:/dc1/rack2"},
+		},
+	}
+
+	testCases := []struct {
+		desc                   string
+		nodeName               string
+		localFaultDomainWeight int
+		expected               int
+	}{
+		{
+			desc:     "local node",
+			nodeName: "local",
+			expected: 20,
+		},
+		{
+			desc:                   "local node with a weight multiplier",
+			nodeName:               "local",
+			localFaultDomainWeight: 3,
+			expected:               6,
+		},
+		{
+			desc:     "remote node",
+			nodeName: "remote",
+			expected: 2,
+		},
+		{
+			desc:     "unknown node",
+			nodeName: "unknown",
+			expected: 2,
+		},
+		{
+			desc:     "local disabling node",
+			nodeName: "local-disabling",
+			expected: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			provider := Provider{
+				LocalFaultDomain:       "fd:/dc1",
+				LocalFaultDomainWeight: test.localFaultDomainWeight,
+				DisablingNodeWeight:    1,
+			}
+			instance := &sf.InstanceItem{
+				ReplicaItemBase: &sf.ReplicaItemBase{NodeName: test.nodeName},
+			}
+
+			assert.Equal(t, test.expected, provider.getNodeWeight(partition, instance, 2))
+		})
+	}
+}
+
+func TestGetFaultDomain(t *testing.T) {
+	partition := PartitionItemExtended{
+		Nodes: map[string]NodeItem{
+			"_Node_0": {Name: "_Node_0", FaultDomain: "fd:/dc1/rack1", UpgradeDomain: "UD0"},
+		},
+	}
+
+	replica := &sf.ReplicaItem{ReplicaItemBase: &sf.ReplicaItemBase{NodeName: "_Node_0"}}
+	assert.Equal(t, "fd:/dc1/rack1", getFaultDomain(partition, replica))
+
+	unknown := &sf.ReplicaItem{ReplicaItemBase: &sf.ReplicaItemBase{NodeName: "unknown"}}
+	assert.Empty(t, getFaultDomain(partition, unknown))
+}
+
+func TestBuildConfigurationLocality(t *testing.T) {
+	newInstance := func(id, nodeName string) sf.InstanceItem {
+		instance := newTestInstance(id, "http://localhost:808"+id)
+		instance.NodeName = nodeName
+		return instance
+	}
+
+	client := &clientMock{
+		applications: apps,
+		services:     services,
+		partitions:   partitions,
+		instances: &sf.InstanceItemsPage{
+			Items: []sf.InstanceItem{
+				newInstance("1", "_Node_0"),
+				newInstance("2", "_Node_1"),
+			},
+		},
+		nodes: &nodeItemsPage{
+			Items: []NodeItem{
+				{Name: "_Node_0", NodeStatus: "Up", FaultDomain: "fd:/dc1/rack1"},
+				{Name: "_Node_1", NodeStatus: "Up", FaultDomain: "fd:/dc2/rack1"},
+			},
+		},
+		getServiceExtensionMapResult: map[string]string{
+			label.TraefikEnable: "true",
+		},
+	}
+
+	provider := Provider{
+		LocalFaultDomain: "fd:/dc1",
+		clusters:         []*clusterConnection{{client: client}},
+	}
+
+	serviceItems, err := provider.getServices()
+	require.NoError(t, err)
+
+	config, err := provider.buildConfiguration(serviceItems)
+	require.NoError(t, err)
+
+	backend := config.Backends["fabric:/TestApplication/TestService"]
+	require.NotNil(t, backend)
+
+	weights := make(map[string]int)
+	for id, server := range backend.Servers {
+		weights[id] = server.Weight
+	}
+	assert.Equal(t, map[string]int{"1": label.DefaultWeight * defaultLocalFaultDomainWeight, "2": label.DefaultWeight}, weights)
+}
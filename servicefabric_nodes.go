@@ -0,0 +1,110 @@
+package servicefabric
+
+import (
+	"fmt"
+
+	sf "github.com/jjcollinge/servicefabric"
+)
+
+// Node statuses of the nodes being drained or unavailable.
+const (
+	nodeStatusDisabling = "Disabling"
+	nodeStatusDisabled  = "Disabled"
+	nodeStatusDown      = "Down"
+)
+
+// nodeFilter holds the nodes of the cluster and the upgrade domains
+// whose replicas are removed while an application upgrade runs.
+type nodeFilter struct {
+	nodes                 map[string]NodeItem
+	drainedUpgradeDomains map[string]struct{}
+}
+
+// listNodes returns the nodes of the cluster keyed by node name.
+func listNodes(sfClient sfClient) (map[string]NodeItem, error) {
+	nodes := make(map[string]NodeItem)
+	var token string
+	for {
+		page, err := sfClient.GetNodes(token)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range page.Items {
+			nodes[node.Name] = node
+		}
+
+		token, err = nextContinuationToken(token, page.ContinuationToken)
+		if err != nil {
+			return nil, err
+		}
+		if token == "" {
+			return nodes, nil
+		}
+	}
+}
+
+// checkNode returns why the replica cannot be routed to because of the
+// status of its node. The replicas on Disabling nodes are kept when
+// DisablingNodeWeight is set, replicas on unknown nodes are always kept.
+// Replicas in a drained upgrade domain are dropped.
+func (p *Provider) checkNode(instanceData *sf.ReplicaItemBase, filter nodeFilter) error {
+	node, exists := filter.nodes[instanceData.NodeName]
+	if !exists {
+		return nil
+	}
+
+	if _, drained := filter.drainedUpgradeDomains[node.UpgradeDomain]; drained {
+		return fmt.Errorf("node %s is in upgrade domain %s being upgraded", node.Name, node.UpgradeDomain)
+	}
+
+	switch node.NodeStatus {
+	case nodeStatusDown, nodeStatusDisabled:
+		return fmt.Errorf("node %s is %s", node.Name, node.NodeStatus)
+	case nodeStatusDisabling:
+		if p.DisablingNodeWeight <= 0 {
+			return fmt.Errorf("node %s is %s", node.Name, node.NodeStatus)
+		}
+	}
+	return nil
+}
+
+// getPartitionNodes returns the nodes hosting the replicas and instances of the partition.
+func getPartitionNodes(nodes map[string]NodeItem, partition PartitionItemExtended) map[string]NodeItem {
+	var nodeNames []string
+	for i := range partition.Replicas {
+		_, data := partition.Replicas[i].GetReplicaData()
+		nodeNames = append(nodeNames, data.NodeName)
+	}
+	for i := range partition.Instances {
+		_, data := partition.Instances[i].GetReplicaData()
+		nodeNames = append(nodeNames, data.NodeName)
+	}
+
+	var partitionNodes map[string]NodeItem
+	for _, name := range nodeNames {
+		if node, exists := nodes[name]; exists {
+			if partitionNodes == nil {
+				partitionNodes = make(map[string]NodeItem)
+			}
+			partitionNodes[name] = node
+		}
+	}
+	return partitionNodes
+}
+
+// getNodeWeight returns the weight of a server given its node: the weight
+// is raised in the local fault domain, and lowered to DisablingNodeWeight
+// on a Disabling node, so it is drained before being removed.
+func (p *Provider) getNodeWeight(partition PartitionItemExtended, instance replicaInstance, weight int) int {
+	weight = p.getLocalityWeight(partition, instance, weight)
+
+	node, exists := getReplicaNode(partition, instance)
+	if !exists || node.NodeStatus != nodeStatusDisabling {
+		return weight
+	}
+
+	if p.DisablingNodeWeight > 0 && p.DisablingNodeWeight < weight {
+		return p.DisablingNodeWeight
+	}
+	return weight
+}
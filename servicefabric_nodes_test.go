@@ -0,0 +1,256 @@
+package servicefabric
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sf "github.com/jjcollinge/servicefabric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/provider/label"
+)
+
+func TestClusterClientGetNodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/Nodes" {
+			http.NotFound(rw, req)
+			return
+		}
+
+		fmt.Fprint(rw, `{"ContinuationToken":"","Items":[
+			{"Name":"_Node_0","NodeStatus":"Up","UpgradeDomain":"0","FaultDomain":"fd:/0","IpAddressOrFQDN":"10.0.0.4"},
+			{"Name":"_Node_1","NodeStatus":"Disabling","UpgradeDomain":"1","FaultDomain":"fd:/1","IpAddressOrFQDN":"10.0.0.5"}
+		]}`)
+	}))
+	defer server.Close()
+
+	client, err := newClusterClient(&http.Client{}, server.URL, "")
+	require.NoError(t, err)
+
+	nodes, err := listNodes(client)
+	require.NoError(t, err)
+
+	expected := map[string]NodeItem{
+		"_Node_0": {Name: "_Node_0", NodeStatus: "Up", UpgradeDomain: "0", FaultDomain: "fd:/0"},
+		"_Node_1": {Name: "_Node_1", NodeStatus: "Disabling", UpgradeDomain: "1", FaultDomain: "fd:/1"},
+	}
+	assert.Equal(t, expected, nodes)
+}
+
+func TestCheckNode(t *testing.T) {
+	nodes := map[string]NodeItem{
+		"up":        {Name: "up", NodeStatus: "Up"},
+		"disabling": {Name: "disabling", NodeStatus: nodeStatusDisabling},
+		"disabled":  {Name: "disabled", NodeStatus: nodeStatusDisabled},
+		"down":      {Name: "down", NodeStatus: nodeStatusDown},
+	}
+
+	testCases := []struct {
+		desc                string
+		nodeName            string
+		disablingNodeWeight int
+		expectedError       string
+	}{
+		{
+			desc:     "node up",
+			nodeName: "up",
+		},
+		{
+			desc:     "unknown node",
+			nodeName: "unknown",
+		},
+		{
+			desc:          "node disabling",
+			nodeName:      "disabling",
+			expectedError: "node disabling is Disabling",
+		},
+		{
+			desc:                "node disabling with a weight",
+			nodeName:            "disabling",
+			disablingNodeWeight: 1,
+		},
+		{
+			desc:                "node disabled",
+			nodeName:            "disabled",
+			disablingNodeWeight: 1,
+			expectedError:       "node disabled is Disabled",
+		},
+		{
+			desc:                "node down",
+			nodeName:            "down",
+			disablingNodeWeight: 1,
+			expectedError:       "node down is Down",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			provider := Provider{DisablingNodeWeight: test.disablingNodeWeight}
+
+			err := provider.checkNode(&sf.ReplicaItemBase{NodeName: test.nodeName}, nodeFilter{nodes: nodes})
+			if test.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.expectedError)
+			}
+		})
+	}
+}
+
+func TestGetNodeWeight(t *testing.T) {
+	partition := PartitionItemExtended{
+		Nodes: map[string]NodeItem{
+			"up":        {Name: "up", NodeStatus: "Up"},
+			"disabling": {Name: "disabling", NodeStatus: nodeStatusDisabling},
+		},
+	}
+
+	testCases := []struct {
+		desc                string
+		nodeName            string
+		weight              int
+		disablingNodeWeight int
+		expected            int
+	}{
+		{
+			desc:                "node up",
+			nodeName:            "up",
+			weight:              10,
+			disablingNodeWeight: 1,
+			expected:            10,
+		},
+		{
+			desc:                "node disabling",
+			nodeName:            "disabling",
+			weight:              10,
+			disablingNodeWeight: 1,
+			expected:            1,
+		},
+		{
+			desc:                "node disabling with a lower weight",
+			nodeName:            "disabling",
+			weight:              1,
+			disablingNodeWeight: 5,
+			expected:            1,
+		},
+		{
+			desc:     "unknown node",
+			nodeName: "unknown",
+			weight:   10,
+			expected: 10,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			provider := Provider{DisablingNodeWeight: test.disablingNodeWeight}
+			instance := &sf.InstanceItem{
+				ReplicaItemBase: &sf.ReplicaItemBase{NodeName: test.nodeName},
+			}
+
+			assert.Equal(t, test.expected, provider.getNodeWeight(partition, instance, test.weight))
+		})
+	}
+}
+
+func TestGetServicesNodeStatus(t *testing.T) {
+	newInstance := func(id, nodeName string) sf.InstanceItem {
+		instance := newTestInstance(id, "http://localhost:808"+id)
+		instance.NodeName = nodeName
+		return instance
+	}
+
+	testCases := []struct {
+		desc                string
+		nodesError          error
+		disablingNodeWeight int
+		expectedInstances   []string
+		expectedWeights     map[string]int
+	}{
+		{
+			desc:              "drop replicas on disabling and down nodes",
+			expectedInstances: []string{"1"},
+			expectedWeights:   map[string]int{"1": 5},
+		},
+		{
+			desc:                "down-weight replicas on disabling nodes",
+			disablingNodeWeight: 1,
+			expectedInstances:   []string{"1", "2"},
+			expectedWeights:     map[string]int{"1": 5, "2": 1},
+		},
+		{
+			desc:              "nodes unavailable",
+			nodesError:        errors.New("nodes unavailable"),
+			expectedInstances: []string{"1", "2", "3"},
+			expectedWeights:   map[string]int{"1": 5, "2": 5, "3": 5},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := &clientMock{
+				applications: apps,
+				services:     services,
+				partitions:   partitions,
+				instances: &sf.InstanceItemsPage{
+					Items: []sf.InstanceItem{
+						newInstance("1", "_Node_0"),
+						newInstance("2", "_Node_1"),
+						newInstance("3", "_Node_2"),
+					},
+				},
+				nodes: &nodeItemsPage{
+					Items: []NodeItem{
+						{Name: "_Node_0", NodeStatus: "Up"},
+						{Name: "_Node_1", NodeStatus: nodeStatusDisabling},
+						{Name: "_Node_2", NodeStatus: nodeStatusDown},
+					},
+				},
+				nodesError: test.nodesError,
+				getServiceExtensionMapResult: map[string]string{
+					label.TraefikEnable: "true",
+					label.TraefikWeight: "5",
+				},
+			}
+
+			provider := Provider{
+				DisablingNodeWeight: test.disablingNodeWeight,
+				clusters:            []*clusterConnection{{client: client}},
+			}
+
+			serviceItems, err := provider.getServices()
+			require.NoError(t, err)
+			require.Len(t, serviceItems, 1)
+
+			var instanceIDs []string
+			for _, instance := range serviceItems[0].Partitions[0].Instances {
+				instanceIDs = append(instanceIDs, instance.ID)
+			}
+			assert.Equal(t, test.expectedInstances, instanceIDs)
+
+			config, err := provider.buildConfiguration(serviceItems)
+			require.NoError(t, err)
+
+			backend := config.Backends["fabric:/TestApplication/TestService"]
+			require.NotNil(t, backend)
+
+			weights := make(map[string]int)
+			for id, server := range backend.Servers {
+				weights[id] = server.Weight
+			}
+			assert.Equal(t, test.expectedWeights, weights)
+		})
+	}
+}
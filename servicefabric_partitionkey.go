@@ -0,0 +1,257 @@
+package servicefabric
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/traefik/traefik/log"
+	"github.com/traefik/traefik/provider/label"
+)
+
+// Partition kinds of sf.PartitionInformation.
+const (
+	partitionKindSingleton  = "Singleton"
+	partitionKindInt64Range = "Int64Range"
+	partitionKindNamed      = "Named"
+)
+
+// traefikFrontendPartitionRulePrefix prefixes the partition ID
+// of the explicit frontend rule of a partition.
+const traefikFrontendPartitionRulePrefix = label.TraefikFrontendRule + ".partition."
+
+const (
+	partitionKeyPlaceholder = "{key}"
+	partitionKeyVariable    = "partitionkey"
+)
+
+// partitionNameForbiddenChars cannot be embedded in a frontend rule
+// or a regular expression without escaping.
+const partitionNameForbiddenChars = "\\\"^[]{},;:= \t"
+
+// getPartitionRule returns the frontend rule of a stateful partition:
+// the traefik.frontend.rule.partition.<ID> label when set, otherwise the
+// traefik.frontend.rule label combined with a rule matching the partition key.
+// It returns an empty rule when the partition cannot be routed.
+func getPartitionRule(service ServiceItemExtended, partition PartitionItemExtended) string {
+	partitionID := partition.PartitionInformation.ID
+	if rule := label.GetStringValue(service.Labels, traefikFrontendPartitionRulePrefix+partitionID, ""); rule != "" {
+		return rule
+	}
+
+	if !hasPartitionKeySource(service) {
+		return ""
+	}
+
+	baseRule := label.GetStringValue(service.Labels, label.TraefikFrontendRule, "")
+	if partition.PartitionInformation.ServicePartitionKind == partitionKindSingleton {
+		return baseRule
+	}
+
+	pattern, err := getPartitionKeyPattern(partition)
+	if err != nil {
+		log.Warnf("Unable to route partition %s of service %s by key: %v", partitionID, service.Name, err)
+		return ""
+	}
+
+	keyRule := getPartitionKeyRule(service, pattern)
+	if keyRule == "" {
+		return ""
+	}
+	if baseRule == "" {
+		return keyRule
+	}
+	return baseRule + ";" + keyRule
+}
+
+func hasPartitionKeySource(service ServiceItemExtended) bool {
+	return label.Has(service.Labels, traefikSFPartitionKeyHeader) ||
+		label.Has(service.Labels, traefikSFPartitionKeyQuery) ||
+		label.Has(service.Labels, traefikSFPartitionKeyPath)
+}
+
+// getPartitionKeyRule matches pattern against the key source of the service,
+// the header takes precedence over the query parameter and the path.
+// It returns an empty rule when the path has no key placeholder.
+func getPartitionKeyRule(service ServiceItemExtended, pattern string) string {
+	if header := label.GetStringValue(service.Labels, traefikSFPartitionKeyHeader, ""); header != "" {
+		return "HeadersRegexp:" + header + ",^" + pattern + "$"
+	}
+
+	if param := label.GetStringValue(service.Labels, traefikSFPartitionKeyQuery, ""); param != "" {
+		return "Query:" + param + "={" + partitionKeyVariable + ":" + pattern + "}"
+	}
+
+	path := label.GetStringValue(service.Labels, traefikSFPartitionKeyPath, "")
+	if !strings.Contains(path, partitionKeyPlaceholder) {
+		log.Warnf("Label %s of service %s has no %s placeholder, its partitions cannot be routed by key", traefikSFPartitionKeyPath, service.Name, partitionKeyPlaceholder)
+		return ""
+	}
+	if !strings.HasSuffix(path, partitionKeyPlaceholder) {
+		// The key must be followed by the end of the path or a slash.
+		return "PathPrefix:" + strings.Replace(path, partitionKeyPlaceholder, "{"+partitionKeyVariable+":"+pattern+"}", 1)
+	}
+	return "PathPrefix:" + strings.Replace(path, partitionKeyPlaceholder, "{"+partitionKeyVariable+":"+pattern+"(?:/|$)}", 1)
+}
+
+// getPartitionKeyPattern returns a regular expression matching the keys of
+// the partition. It contains neither backslashes nor commas so it can be
+// written as is in a frontend rule.
+func getPartitionKeyPattern(partition PartitionItemExtended) (string, error) {
+	info := partition.PartitionInformation
+
+	switch info.ServicePartitionKind {
+	case partitionKindNamed:
+		if partition.Name == "" {
+			return "", fmt.Errorf("missing partition name")
+		}
+		if strings.ContainsAny(partition.Name, partitionNameForbiddenChars) {
+			return "", fmt.Errorf("unsupported characters in partition name %q", partition.Name)
+		}
+		return "(?:" + quoteRegexp(partition.Name) + ")", nil
+
+	case partitionKindInt64Range:
+		low, err := strconv.ParseInt(info.LowKey, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid low key %q: %w", info.LowKey, err)
+		}
+		high, err := strconv.ParseInt(info.HighKey, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid high key %q: %w", info.HighKey, err)
+		}
+		if low > high {
+			return "", fmt.Errorf("low key %d greater than high key %d", low, high)
+		}
+		return int64RangePattern(low, high), nil
+
+	default:
+		return "", fmt.Errorf("unsupported partition kind %q", info.ServicePartitionKind)
+	}
+}
+
+// quoteRegexp escapes the regular expression metacharacters
+// with character classes instead of backslashes.
+func quoteRegexp(value string) string {
+	var builder strings.Builder
+	for _, char := range value {
+		if strings.ContainsRune(".+*?()|$", char) {
+			builder.WriteString("[" + string(char) + "]")
+		} else {
+			builder.WriteRune(char)
+		}
+	}
+	return builder.String()
+}
+
+// int64RangePattern returns a regular expression matching
+// the decimal integers between low and high included.
+func int64RangePattern(low, high int64) string {
+	var patterns []string
+
+	if low < 0 {
+		negativeHigh := high
+		if negativeHigh >= 0 {
+			negativeHigh = -1
+		}
+		for _, pattern := range uintRangePatterns(magnitude(negativeHigh), magnitude(low)) {
+			patterns = append(patterns, "-"+pattern)
+		}
+	}
+
+	if high >= 0 {
+		positiveLow := low
+		if positiveLow < 0 {
+			positiveLow = 0
+		}
+		patterns = append(patterns, uintRangePatterns(uint64(positiveLow), uint64(high))...)
+	}
+
+	return "(?:" + strings.Join(patterns, "|") + ")"
+}
+
+// magnitude returns the absolute value of a negative value, without
+// overflowing on the minimum int64.
+func magnitude(value int64) uint64 {
+	return uint64(-(value + 1)) + 1
+}
+
+// uintRangePatterns splits the range by number of digits, the bounds of
+// each part have the same length.
+func uintRangePatterns(low, high uint64) []string {
+	var patterns []string
+	for low <= high {
+		digits := len(strconv.FormatUint(low, 10))
+
+		upper := high
+		if digits < 19 {
+			if largest := pow10(digits) - 1; largest < high {
+				upper = largest
+			}
+		}
+
+		patterns = append(patterns, digitRangePatterns(strconv.FormatUint(low, 10), strconv.FormatUint(upper, 10))...)
+		if upper == high {
+			break
+		}
+		low = upper + 1
+	}
+	return patterns
+}
+
+func pow10(exponent int) uint64 {
+	result := uint64(1)
+	for i := 0; i < exponent; i++ {
+		result *= 10
+	}
+	return result
+}
+
+// digitRangePatterns returns the patterns matching the numbers between
+// low and high, which have the same number of digits.
+func digitRangePatterns(low, high string) []string {
+	if low == high {
+		return []string{low}
+	}
+
+	if low[0] == high[0] {
+		var patterns []string
+		for _, pattern := range digitRangePatterns(low[1:], high[1:]) {
+			patterns = append(patterns, low[:1]+pattern)
+		}
+		return patterns
+	}
+
+	rest := len(low) - 1
+	anyDigits := strings.Repeat("[0-9]", rest)
+
+	var patterns []string
+
+	first := low[0]
+	if strings.Trim(low[1:], "0") != "" {
+		for _, pattern := range digitRangePatterns(low[1:], strings.Repeat("9", rest)) {
+			patterns = append(patterns, low[:1]+pattern)
+		}
+		first++
+	}
+
+	last := high[0]
+	var lastPatterns []string
+	if strings.Trim(high[1:], "9") != "" {
+		for _, pattern := range digitRangePatterns(strings.Repeat("0", rest), high[1:]) {
+			lastPatterns = append(lastPatterns, high[:1]+pattern)
+		}
+		last--
+	}
+
+	if first <= last {
+		patterns = append(patterns, digitClass(first, last)+anyDigits)
+	}
+	return append(patterns, lastPatterns...)
+}
+
+func digitClass(first, last byte) string {
+	if first == last {
+		return string(first)
+	}
+	return "[" + string(first) + "-" + string(last) + "]"
+}
@@ -0,0 +1,312 @@
+package servicefabric
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/containous/mux"
+	sf "github.com/jjcollinge/servicefabric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/provider/label"
+)
+
+func TestInt64RangePattern(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		low        int64
+		high       int64
+		matching   []string
+		unmatching []string
+	}{
+		{
+			desc:       "single value",
+			low:        42,
+			high:       42,
+			matching:   []string{"42"},
+			unmatching: []string{"41", "43", "420", "042", "-42"},
+		},
+		{
+			desc:       "positive range",
+			low:        17,
+			high:       2345,
+			matching:   []string{"17", "19", "20", "99", "100", "999", "1000", "2299", "2339", "2345"},
+			unmatching: []string{"16", "2346", "2400", "9", "017", "-17"},
+		},
+		{
+			desc:       "negative range",
+			low:        -300,
+			high:       -5,
+			matching:   []string{"-300", "-299", "-100", "-99", "-10", "-5"},
+			unmatching: []string{"-301", "-4", "-0", "5", "300"},
+		},
+		{
+			desc:       "range around zero",
+			low:        -10,
+			high:       10,
+			matching:   []string{"-10", "-1", "0", "1", "10"},
+			unmatching: []string{"-11", "11", "-0", "00"},
+		},
+		{
+			desc:     "full int64 range",
+			low:      math.MinInt64,
+			high:     math.MaxInt64,
+			matching: []string{"-9223372036854775808", "-1", "0", "9223372036854775807", "123456789"},
+			unmatching: []string{
+				"-9223372036854775809",
+				"9223372036854775808",
+				"10000000000000000000",
+				"abc",
+			},
+		},
+		{
+			desc:       "upper half of int64",
+			low:        0,
+			high:       math.MaxInt64,
+			matching:   []string{"0", "4611686018427387903", "9223372036854775807"},
+			unmatching: []string{"-1", "9223372036854775808"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			pattern := int64RangePattern(test.low, test.high)
+			assert.NotContains(t, pattern, `\`)
+			assert.NotContains(t, pattern, ",")
+
+			matcher := regexp.MustCompile("^" + pattern + "$")
+			for _, value := range test.matching {
+				assert.True(t, matcher.MatchString(value), "%s should match %s", value, pattern)
+			}
+			for _, value := range test.unmatching {
+				assert.False(t, matcher.MatchString(value), "%s should not match %s", value, pattern)
+			}
+		})
+	}
+}
+
+func TestInt64RangePatternRandomRanges(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		low := random.Int63n(200000) - 100000
+		high := low + random.Int63n(50000)
+
+		matcher := regexp.MustCompile("^" + int64RangePattern(low, high) + "$")
+		for value := low - 1000; value <= high+1000; value += 7 {
+			expected := value >= low && value <= high
+			if matcher.MatchString(strconv.FormatInt(value, 10)) != expected {
+				t.Fatalf("range [%d, %d]: unexpected match result for %d", low, high, value)
+			}
+		}
+	}
+}
+
+func TestGetPartitionRule(t *testing.T) {
+	int64Partition := PartitionItemExtended{
+		PartitionItem: sf.PartitionItem{
+			PartitionInformation: sf.PartitionInformation{
+				ID:                   "bce46a8c-b62d-4996-89dc-7ffc00a96902",
+				LowKey:               "0",
+				HighKey:              "99",
+				ServicePartitionKind: partitionKindInt64Range,
+			},
+		},
+	}
+	namedPartition := PartitionItemExtended{
+		PartitionItem: sf.PartitionItem{
+			PartitionInformation: sf.PartitionInformation{
+				ID:                   "c6a2d1a4-8bd8-4b1a-a1e5-5b4e0bb1d7e1",
+				ServicePartitionKind: partitionKindNamed,
+			},
+		},
+		Name: "west.eu",
+	}
+	singletonPartition := PartitionItemExtended{
+		PartitionItem: sf.PartitionItem{
+			PartitionInformation: sf.PartitionInformation{
+				ID:                   "1b8c5a0e-4d2f-4b43-9f61-8b8d6f0f3d4e",
+				ServicePartitionKind: partitionKindSingleton,
+			},
+		},
+	}
+
+	testCases := []struct {
+		desc       string
+		labels     map[string]string
+		partition  PartitionItemExtended
+		expected   string
+		matching   []string
+		unmatching []string
+	}{
+		{
+			desc:      "without key source",
+			labels:    map[string]string{},
+			partition: int64Partition,
+			expected:  "",
+		},
+		{
+			desc: "explicit partition rule",
+			labels: map[string]string{
+				traefikSFPartitionKeyHeader: "X-Partition-Key",
+				"traefik.frontend.rule.partition.bce46a8c-b62d-4996-89dc-7ffc00a96902": "Host:explicit.com",
+			},
+			partition: int64Partition,
+			expected:  "Host:explicit.com",
+		},
+		{
+			desc: "Int64Range by header",
+			labels: map[string]string{
+				traefikSFPartitionKeyHeader: "X-Partition-Key",
+			},
+			partition:  int64Partition,
+			expected:   "HeadersRegexp:X-Partition-Key,^(?:[0-9]|[1-9][0-9])$",
+			matching:   []string{"/?h=0", "/?h=42", "/?h=99"},
+			unmatching: []string{"/?h=100", "/?h=-1", "/"},
+		},
+		{
+			desc: "Int64Range by query with base rule",
+			labels: map[string]string{
+				label.TraefikFrontendRule:  "PathPrefix:/api",
+				traefikSFPartitionKeyQuery: "key",
+			},
+			partition:  int64Partition,
+			expected:   "PathPrefix:/api;Query:key={partitionkey:(?:[0-9]|[1-9][0-9])}",
+			matching:   []string{"/api?key=7", "/api/orders?key=99"},
+			unmatching: []string{"/api?key=100", "/other?key=7", "/api"},
+		},
+		{
+			desc: "Int64Range by last path segment",
+			labels: map[string]string{
+				traefikSFPartitionKeyPath: "/orders/{key}",
+			},
+			partition:  int64Partition,
+			expected:   "PathPrefix:/orders/{partitionkey:(?:[0-9]|[1-9][0-9])(?:/|$)}",
+			matching:   []string{"/orders/5", "/orders/55/items"},
+			unmatching: []string{"/orders/555", "/orders/5x", "/orders/"},
+		},
+		{
+			desc: "Int64Range by inner path segment",
+			labels: map[string]string{
+				traefikSFPartitionKeyPath: "/tenants/{key}/",
+			},
+			partition:  int64Partition,
+			expected:   "PathPrefix:/tenants/{partitionkey:(?:[0-9]|[1-9][0-9])}/",
+			matching:   []string{"/tenants/12/", "/tenants/12/users"},
+			unmatching: []string{"/tenants/123/", "/tenants/12"},
+		},
+		{
+			desc: "path without key placeholder",
+			labels: map[string]string{
+				label.TraefikFrontendRule: "Host:foo.com",
+				traefikSFPartitionKeyPath: "/api",
+			},
+			partition: int64Partition,
+			expected:  "",
+		},
+		{
+			desc: "Named by header",
+			labels: map[string]string{
+				traefikSFPartitionKeyHeader: "X-Region",
+			},
+			partition:  namedPartition,
+			expected:   "HeadersRegexp:X-Region,^(?:west[.]eu)$",
+			matching:   []string{"/?h=west.eu"},
+			unmatching: []string{"/?h=westxeu", "/?h=west.eu2", "/?h=east"},
+		},
+		{
+			desc: "Named with unsupported characters",
+			labels: map[string]string{
+				traefikSFPartitionKeyHeader: "X-Region",
+			},
+			partition: PartitionItemExtended{
+				PartitionItem: namedPartition.PartitionItem,
+				Name:          "west,eu",
+			},
+			expected: "",
+		},
+		{
+			desc: "Singleton",
+			labels: map[string]string{
+				label.TraefikFrontendRule:   "Host:singleton.com",
+				traefikSFPartitionKeyHeader: "X-Partition-Key",
+			},
+			partition: singletonPartition,
+			expected:  "Host:singleton.com",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			service := ServiceItemExtended{
+				ServiceItem: sf.ServiceItem{Name: "fabric:/TestApplication/TestService"},
+				Labels:      test.labels,
+			}
+
+			rule := getPartitionRule(service, test.partition)
+			assert.Equal(t, test.expected, rule)
+
+			if len(test.matching) == 0 && len(test.unmatching) == 0 {
+				return
+			}
+
+			route := parseTestRule(t, rule)
+			for _, target := range test.matching {
+				assert.True(t, matchRoute(route, target, test.labels), "%s should match %s", target, rule)
+			}
+			for _, target := range test.unmatching {
+				assert.False(t, matchRoute(route, target, test.labels), "%s should not match %s", target, rule)
+			}
+		})
+	}
+}
+
+// parseTestRule builds the route of the matchers used by getPartitionRule
+// the way the Traefik rule parser does.
+func parseTestRule(t *testing.T, rule string) *mux.Route {
+	t.Helper()
+
+	route := mux.NewRouter().NewRoute()
+	for _, matcher := range strings.Split(rule, ";") {
+		parts := strings.SplitN(matcher, ":", 2)
+		require.Len(t, parts, 2, matcher)
+
+		args := strings.Split(parts[1], ",")
+		switch parts[0] {
+		case "PathPrefix":
+			route = route.PathPrefix(args[0])
+		case "HeadersRegexp":
+			route = route.HeadersRegexp(args...)
+		case "Query":
+			route = route.Queries(strings.SplitN(args[0], "=", 2)...)
+		default:
+			t.Fatalf("unexpected matcher %s", matcher)
+		}
+		require.NoError(t, route.GetError())
+	}
+	return route
+}
+
+// matchRoute requests target, the h query parameter
+// is sent as the partition key header instead.
+func matchRoute(route *mux.Route, target string, labels map[string]string) bool {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if header := labels[traefikSFPartitionKeyHeader]; header != "" {
+		if value := req.URL.Query().Get("h"); value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+	return route.Match(req, &mux.RouteMatch{})
+}
@@ -0,0 +1,87 @@
+package servicefabric
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const defaultDiscoveryWorkers = 8
+
+// workerPool bounds the number of Service Fabric API requests
+// running at the same time during discovery.
+type workerPool struct {
+	slots chan struct{}
+}
+
+func newWorkerPool(size int) *workerPool {
+	if size <= 0 {
+		size = defaultDiscoveryWorkers
+	}
+	return &workerPool{slots: make(chan struct{}, size)}
+}
+
+// run waits for a free slot and calls fn in the current goroutine.
+// fn must not call run itself, otherwise nested calls can starve the pool.
+func (w *workerPool) run(fn func()) {
+	w.slots <- struct{}{}
+	defer func() { <-w.slots }()
+
+	fn()
+}
+
+// forEach calls fn concurrently for each index in [0, n) and
+// waits for all of them to return.
+// Callers write their results by index to keep a stable order.
+func forEach(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// discoveryError holds the discovery failures keyed by application ID.
+type discoveryError map[string]error
+
+func (e discoveryError) Error() string {
+	ids := make([]string, 0, len(e))
+	for id := range e {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	msgs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		msgs = append(msgs, fmt.Sprintf("application %s: %v", id, e[id]))
+	}
+	return "discovery failed for " + strings.Join(msgs, "; ")
+}
+
+// joinErrors combines the errors of the discovery branches,
+// it returns nil when none of them failed.
+func joinErrors(errs []error) error {
+	var msgs []string
+	var last error
+	for _, err := range errs {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+			last = err
+		}
+	}
+
+	switch len(msgs) {
+	case 0:
+		return nil
+	case 1:
+		return last
+	default:
+		return errors.New(strings.Join(msgs, "; "))
+	}
+}
@@ -0,0 +1,100 @@
+package servicefabric
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/traefik/traefik/log"
+	"github.com/traefik/traefik/provider/label"
+)
+
+// getSegmentServices returns a service per label segment, e.g. the
+// traefik.<segment>.frontend.* and traefik.<segment>.portName labels.
+// The labels of a segment are merged with the default ones and with the
+// traefik.servicefabric.* labels, the segment services are sorted by name.
+// The traefik.servicefabric.endpointnames label adds a segment per
+// endpoint name, named after the endpoint.
+// A service without segments is returned as is.
+func getSegmentServices(services []ServiceItemExtended) []ServiceItemExtended {
+	var segmentServices []ServiceItemExtended
+	for _, service := range services {
+		segmentServices = append(segmentServices, getServiceSegments(service)...)
+	}
+	return segmentServices
+}
+
+func getServiceSegments(service ServiceItemExtended) []ServiceItemExtended {
+	providerLabels := make(map[string]string)
+	traefikLabels := make(map[string]string)
+	for key, value := range service.Labels {
+		// The traefik.servicefabric.group.frontend.* labels would be
+		// read as the frontend labels of a servicefabric.group segment.
+		if strings.HasPrefix(key, traefikSFPrefix) || !strings.HasPrefix(key, label.Prefix) {
+			providerLabels[key] = value
+		} else {
+			traefikLabels[key] = value
+		}
+	}
+
+	// Each endpoint name is a segment exposing the endpoint,
+	// unless the segment sets another one.
+	for _, endpointName := range label.GetSliceStringValue(service.Labels, traefikSFEndpointNames) {
+		portNameKey := label.Prefix + endpointName + "." + label.SuffixPortName
+		if _, exists := traefikLabels[portNameKey]; !exists {
+			traefikLabels[portNameKey] = endpointName
+		}
+	}
+
+	segments := label.ExtractTraefikLabels(traefikLabels)
+	if _, exists := segments[""]; exists {
+		warnUnsupportedPort(service)
+		return []ServiceItemExtended{service}
+	}
+
+	names := segments.GetSegmentNames()
+	sort.Strings(names)
+
+	segmentServices := make([]ServiceItemExtended, 0, len(names))
+	for _, name := range names {
+		labels := make(map[string]string, len(providerLabels)+len(segments[name]))
+		for key, value := range providerLabels {
+			labels[key] = value
+		}
+		for key, value := range segments[name] {
+			labels[key] = value
+		}
+
+		segmentService := service
+		segmentService.Segment = name
+		segmentService.Labels = labels
+		warnUnsupportedPort(segmentService)
+		segmentServices = append(segmentServices, segmentService)
+	}
+	return segmentServices
+}
+
+// warnUnsupportedPort logs the port labels, which are not applied: the
+// servers use the address of a replica endpoint, picked by its name.
+func warnUnsupportedPort(service ServiceItemExtended) {
+	if label.Has(service.Labels, label.TraefikPort) {
+		log.Warnf("Ignoring label %s of service %s, set %s to the name of the replica endpoint to use instead",
+			getSegmentLabelName(service.Segment, label.SuffixPort), getServiceName(service), getSegmentLabelName(service.Segment, label.SuffixPortName))
+	}
+}
+
+func getSegmentLabelName(segment, suffix string) string {
+	if segment == "" {
+		return label.Prefix + suffix
+	}
+	return label.Prefix + segment + "." + suffix
+}
+
+// getEndpointName returns the name of the replica endpoint the service
+// is exposed on, the portName label of a segment takes precedence over
+// the traefik.servicefabric.endpointname label.
+func getEndpointName(service ServiceItemExtended) string {
+	if name := label.GetStringValue(service.Labels, label.TraefikPortName, ""); name != "" {
+		return name
+	}
+	return label.GetStringValue(service.Labels, traefikSFEndpointName, "")
+}
@@ -0,0 +1,173 @@
+package servicefabric
+
+import (
+	"testing"
+
+	sf "github.com/jjcollinge/servicefabric"
+	"github.com/stretchr/testify/assert"
+	"github.com/traefik/traefik/provider/label"
+)
+
+func TestGetSegmentServices(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected map[string]map[string]string
+	}{
+		{
+			desc: "without segments",
+			labels: map[string]string{
+				label.TraefikEnable:       "true",
+				label.TraefikFrontendRule: "Host:foo.com",
+			},
+			expected: map[string]map[string]string{
+				"": {
+					label.TraefikEnable:       "true",
+					label.TraefikFrontendRule: "Host:foo.com",
+				},
+			},
+		},
+		{
+			desc: "with segments",
+			labels: map[string]string{
+				label.TraefikEnable:                     "true",
+				label.TraefikFrontendPassHostHeader:     "false",
+				traefikSFEndpointName:                   "api",
+				"traefik.public.frontend.rule":          "Host:foo.com",
+				"traefik.internal.frontend.rule":        "Host:foo.internal",
+				"traefik.internal.frontend.entryPoints": "internal",
+				"traefik.internal.portName":             "admin",
+			},
+			expected: map[string]map[string]string{
+				"internal": {
+					label.TraefikEnable:                 "true",
+					label.TraefikFrontendPassHostHeader: "false",
+					traefikSFEndpointName:               "api",
+					label.TraefikFrontendRule:           "Host:foo.internal",
+					label.TraefikFrontendEntryPoints:    "internal",
+					label.TraefikPortName:               "admin",
+				},
+				"public": {
+					label.TraefikEnable:                 "true",
+					label.TraefikFrontendPassHostHeader: "false",
+					traefikSFEndpointName:               "api",
+					label.TraefikFrontendRule:           "Host:foo.com",
+				},
+			},
+		},
+		{
+			desc: "with endpoint names",
+			labels: map[string]string{
+				label.TraefikEnable:           "true",
+				traefikSFEndpointNames:        "api, admin",
+				"traefik.admin.frontend.rule": "Host:admin.com",
+			},
+			expected: map[string]map[string]string{
+				"admin": {
+					label.TraefikEnable:       "true",
+					traefikSFEndpointNames:    "api, admin",
+					label.TraefikFrontendRule: "Host:admin.com",
+					label.TraefikPortName:     "admin",
+				},
+				"api": {
+					label.TraefikEnable:    "true",
+					traefikSFEndpointNames: "api, admin",
+					label.TraefikPortName:  "api",
+				},
+			},
+		},
+		{
+			desc: "with endpoint names and segment port name",
+			labels: map[string]string{
+				label.TraefikEnable:    "true",
+				traefikSFEndpointNames: "api",
+				"traefik.api.portName": "v2",
+			},
+			expected: map[string]map[string]string{
+				"api": {
+					label.TraefikEnable:    "true",
+					traefikSFEndpointNames: "api",
+					label.TraefikPortName:  "v2",
+				},
+			},
+		},
+		{
+			desc: "with group labels",
+			labels: map[string]string{
+				label.TraefikEnable:                         "true",
+				traefikSFGroupName:                          "group",
+				"traefik.servicefabric.group.frontend.rule": "Host:group.com",
+			},
+			expected: map[string]map[string]string{
+				"": {
+					label.TraefikEnable:                         "true",
+					traefikSFGroupName:                          "group",
+					"traefik.servicefabric.group.frontend.rule": "Host:group.com",
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			service := ServiceItemExtended{
+				ServiceItem: sf.ServiceItem{Name: "fabric:/TestApplication/TestService"},
+				Labels:      test.labels,
+			}
+
+			segments := make(map[string]map[string]string)
+			for _, segment := range getSegmentServices([]ServiceItemExtended{service}) {
+				assert.Equal(t, service.Name, segment.Name)
+				segments[segment.Segment] = segment.Labels
+			}
+			assert.Equal(t, test.expected, segments)
+		})
+	}
+}
+
+func TestGetEndpointName(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected string
+	}{
+		{
+			desc:     "default endpoint",
+			labels:   map[string]string{},
+			expected: "",
+		},
+		{
+			desc: "endpoint name label",
+			labels: map[string]string{
+				traefikSFEndpointName: "api",
+			},
+			expected: "api",
+		},
+		{
+			desc: "segment port name",
+			labels: map[string]string{
+				traefikSFEndpointName: "api",
+				label.TraefikPortName: "admin",
+			},
+			expected: "admin",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, getEndpointName(ServiceItemExtended{Labels: test.labels}))
+		})
+	}
+}
+
+func TestGetSegmentLabelName(t *testing.T) {
+	assert.Equal(t, "traefik.port", getSegmentLabelName("", label.SuffixPort))
+	assert.Equal(t, "traefik.public.port", getSegmentLabelName("public", label.SuffixPort))
+	assert.Equal(t, "traefik.public.portName", getSegmentLabelName("public", label.SuffixPortName))
+}
@@ -2,6 +2,10 @@ package servicefabric
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -117,7 +121,7 @@ func TestUpdateConfig(t *testing.T) {
 	}
 
 	provider := Provider{
-		sfClient: client,
+		clusters: []*clusterConnection{{client: client}},
 	}
 	configurationChan := make(chan types.ConfigMessage)
 	ctx := context.Background()
@@ -162,13 +166,169 @@ func TestGetLabelsDisableLabelOverrides(t *testing.T) {
 		expectedPropertyName:         services.Items[0].ID,
 	}
 
-	res, err := getLabels(client, &services.Items[0], &apps.Items[0])
+	res, err := getLabels(client, &services.Items[0], &apps.Items[0], getApplicationLabels(client, &apps.Items[0]))
 	require.NoError(t, err)
 
 	_, exists := res["shouldnotexist"]
 	assert.False(t, exists)
 }
 
+func TestGetLabelsApplicationLabels(t *testing.T) {
+	testCases := []struct {
+		desc                  string
+		parameters            []*sf.AppParameter
+		applicationProperties map[string]string
+		extensionLabels       map[string]string
+		serviceProperties     map[string]string
+		expected              map[string]string
+	}{
+		{
+			desc: "application parameters",
+			parameters: []*sf.AppParameter{
+				{Key: label.TraefikFrontendEntryPoints, Value: "https"},
+				{Key: "TraefikPublish", Value: "fabric:/TestApplication/TestService"},
+			},
+			extensionLabels: map[string]string{label.TraefikEnable: "true"},
+			expected: map[string]string{
+				label.TraefikEnable:              "true",
+				label.TraefikFrontendEntryPoints: "https",
+			},
+		},
+		{
+			desc:                  "application properties",
+			applicationProperties: map[string]string{label.TraefikFrontendEntryPoints: "https"},
+			extensionLabels:       map[string]string{label.TraefikEnable: "true"},
+			expected: map[string]string{
+				label.TraefikEnable:              "true",
+				label.TraefikFrontendEntryPoints: "https",
+			},
+		},
+		{
+			desc: "application properties take precedence over application parameters",
+			parameters: []*sf.AppParameter{
+				{Key: label.TraefikFrontendEntryPoints, Value: "http"},
+			},
+			applicationProperties: map[string]string{label.TraefikFrontendEntryPoints: "https"},
+			expected: map[string]string{
+				label.TraefikFrontendEntryPoints: "https",
+			},
+		},
+		{
+			desc: "service labels take precedence",
+			parameters: []*sf.AppParameter{
+				{Key: label.TraefikFrontendEntryPoints, Value: "http"},
+				{Key: label.TraefikWeight, Value: "2"},
+				{Key: label.TraefikProtocol, Value: "https"},
+			},
+			applicationProperties: map[string]string{
+				label.TraefikWeight:   "3",
+				label.TraefikProtocol: "h2c",
+			},
+			extensionLabels: map[string]string{
+				label.TraefikFrontendEntryPoints: "https",
+				label.TraefikWeight:              "10",
+			},
+			serviceProperties: map[string]string{
+				label.TraefikProtocol: "http",
+			},
+			expected: map[string]string{
+				label.TraefikFrontendEntryPoints: "https",
+				label.TraefikWeight:              "10",
+				label.TraefikProtocol:            "http",
+			},
+		},
+		{
+			desc: "application disabling label overrides",
+			parameters: []*sf.AppParameter{
+				{Key: traefikSFEnableLabelOverrides, Value: "false"},
+			},
+			applicationProperties: map[string]string{label.TraefikFrontendEntryPoints: "https"},
+			serviceProperties:     map[string]string{label.TraefikWeight: "10"},
+			expected: map[string]string{
+				traefikSFEnableLabelOverrides: "false",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			app := apps.Items[0]
+			app.Parameters = test.parameters
+
+			properties := make(map[string]map[string]string)
+			if test.applicationProperties != nil {
+				properties[app.ID] = test.applicationProperties
+			}
+			if test.serviceProperties != nil {
+				properties[services.Items[0].ID] = test.serviceProperties
+			}
+
+			client := &clientMock{
+				getServiceExtensionMapResult: test.extensionLabels,
+				properties:                   properties,
+			}
+
+			res, err := getLabels(client, &services.Items[0], &app, getApplicationLabels(client, &app))
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestGetServicesApplicationLabelsOnce(t *testing.T) {
+	secondService := services.Items[0]
+	secondService.ID = "TestApplication/OtherService"
+	secondService.Name = "fabric:/TestApplication/OtherService"
+
+	client := &propertiesClientMock{
+		clientMock: &clientMock{
+			applications: apps,
+			services:     &sf.ServiceItemsPage{Items: []sf.ServiceItem{services.Items[0], secondService}},
+			partitions:   partitions,
+			instances:    instances,
+			getServiceExtensionMapResult: map[string]string{
+				label.TraefikEnable: "true",
+			},
+			properties: map[string]map[string]string{
+				apps.Items[0].ID: {label.TraefikFrontendEntryPoints: "https"},
+			},
+		},
+		calls: make(map[string]int),
+	}
+
+	provider := Provider{
+		clusters: []*clusterConnection{{client: client}},
+	}
+
+	serviceItems, err := provider.getServices()
+	require.NoError(t, err)
+	require.Len(t, serviceItems, 2)
+
+	for _, service := range serviceItems {
+		assert.Equal(t, "https", service.Labels[label.TraefikFrontendEntryPoints])
+	}
+	assert.Equal(t, 1, client.calls[apps.Items[0].ID])
+}
+
+// propertiesClientMock counts the property lookups of each name.
+type propertiesClientMock struct {
+	*clientMock
+	lock  sync.Mutex
+	calls map[string]int
+}
+
+func (c *propertiesClientMock) GetProperties(name string) (bool, map[string]string, error) {
+	c.lock.Lock()
+	c.calls[name]++
+	c.lock.Unlock()
+
+	return c.clientMock.GetProperties(name)
+}
+
 func TestIsHealthy(t *testing.T) {
 	testCases := []struct {
 		desc     string
@@ -230,7 +390,7 @@ func TestIsHealthy(t *testing.T) {
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			healthy := isHealthy(test.replica.ReplicaItemBase)
+			healthy := isHealthy(test.replica.ReplicaItemBase, nil)
 
 			if !healthy && test.expected || healthy && !test.expected {
 				t.Errorf("Incorrectly identified healthy state of a replica. Got %v, expected %v", healthy, test.expected)
@@ -239,6 +399,166 @@ func TestIsHealthy(t *testing.T) {
 	}
 }
 
+func TestIsHealthyWithPolicy(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		policy        *HealthPolicy
+		replicaStatus string
+		healthState   string
+		expected      bool
+	}{
+		{
+			desc:          "default policy with warning",
+			replicaStatus: "Ready",
+			healthState:   "Warning",
+			expected:      true,
+		},
+		{
+			desc:          "default policy with standby",
+			replicaStatus: "Standby",
+			healthState:   "Ok",
+			expected:      false,
+		},
+		{
+			desc: "excluding warning",
+			policy: &HealthPolicy{
+				ReplicaStatuses:      []string{"Ready"},
+				ExcludedHealthStates: []string{"Error", "Warning"},
+			},
+			replicaStatus: "Ready",
+			healthState:   "Warning",
+			expected:      false,
+		},
+		{
+			desc: "including standby",
+			policy: &HealthPolicy{
+				ReplicaStatuses:      []string{"Ready", "Standby"},
+				ExcludedHealthStates: []string{"Error"},
+			},
+			replicaStatus: "Standby",
+			healthState:   "Ok",
+			expected:      true,
+		},
+		{
+			desc: "including in build",
+			policy: &HealthPolicy{
+				ReplicaStatuses: []string{"ready", "inbuild"},
+			},
+			replicaStatus: "InBuild",
+			healthState:   "Warning",
+			expected:      true,
+		},
+		{
+			desc: "default excluded health states",
+			policy: &HealthPolicy{
+				ReplicaStatuses: []string{"ready", "inbuild"},
+			},
+			replicaStatus: "InBuild",
+			healthState:   "Error",
+			expected:      false,
+		},
+		{
+			desc: "excluding no health state",
+			policy: &HealthPolicy{
+				ReplicaStatuses:      []string{"Ready"},
+				ExcludedHealthStates: []string{},
+			},
+			replicaStatus: "Ready",
+			healthState:   "Error",
+			expected:      true,
+		},
+		{
+			desc: "down replica",
+			policy: &HealthPolicy{
+				ReplicaStatuses: []string{"Ready", "Standby"},
+			},
+			replicaStatus: "Down",
+			healthState:   "Ok",
+			expected:      false,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			replica := &sf.ReplicaItemBase{
+				HealthState:   test.healthState,
+				ReplicaStatus: test.replicaStatus,
+			}
+
+			assert.Equal(t, test.expected, isHealthy(replica, test.policy))
+		})
+	}
+}
+
+func TestGetHealthPolicy(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		policy   *HealthPolicy
+		labels   map[string]string
+		expected *HealthPolicy
+	}{
+		{
+			desc:   "default policy",
+			labels: map[string]string{},
+			expected: &HealthPolicy{
+				ReplicaStatuses:      []string{"Ready"},
+				ExcludedHealthStates: []string{"Error"},
+			},
+		},
+		{
+			desc: "provider policy",
+			policy: &HealthPolicy{
+				ExcludedHealthStates: []string{"Error", "Warning"},
+			},
+			labels: map[string]string{},
+			expected: &HealthPolicy{
+				ReplicaStatuses:      []string{"Ready"},
+				ExcludedHealthStates: []string{"Error", "Warning"},
+			},
+		},
+		{
+			desc: "provider policy excluding no health state",
+			policy: &HealthPolicy{
+				ExcludedHealthStates: []string{},
+			},
+			labels: map[string]string{},
+			expected: &HealthPolicy{
+				ReplicaStatuses:      []string{"Ready"},
+				ExcludedHealthStates: []string{},
+			},
+		},
+		{
+			desc: "service labels",
+			policy: &HealthPolicy{
+				ReplicaStatuses:      []string{"Ready"},
+				ExcludedHealthStates: []string{"Error", "Warning"},
+			},
+			labels: map[string]string{
+				traefikSFHealthReplicaStatuses:      "Ready, Standby",
+				traefikSFHealthExcludedHealthStates: "Error",
+			},
+			expected: &HealthPolicy{
+				ReplicaStatuses:      []string{"Ready", "Standby"},
+				ExcludedHealthStates: []string{"Error"},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			provider := Provider{HealthPolicy: test.policy}
+
+			assert.Equal(t, test.expected, provider.getHealthPolicy(test.labels))
+		})
+	}
+}
+
 func TestIsStateX(t *testing.T) {
 	testCases := []struct {
 		desc              string
@@ -291,6 +611,7 @@ func TestGetReplicaDefaultEndpoint(t *testing.T) {
 	testCases := []struct {
 		desc             string
 		replicaData      *sf.ReplicaItemBase
+		preferredNames   []string
 		expectedEndpoint string
 		errorExpected    bool
 	}{
@@ -308,6 +629,57 @@ func TestGetReplicaDefaultEndpoint(t *testing.T) {
 			},
 			errorExpected: true,
 		},
+		{
+			desc: "non HTTP scheme containing http",
+			replicaData: &sf.ReplicaItemBase{
+				Address: `{"Endpoints":{"":"tcp://httpbin:80"}}`,
+			},
+			errorExpected: true,
+		},
+		{
+			desc: "missing host",
+			replicaData: &sf.ReplicaItemBase{
+				Address: `{"Endpoints":{"":"http:///path"}}`,
+			},
+			errorExpected: true,
+		},
+		{
+			desc: "HTTP endpoint among non HTTP endpoints",
+			replicaData: &sf.ReplicaItemBase{
+				Address: `{"Endpoints":{"a":"tcp://httpbin:80","b":"net.tcp://localhost:8082","c":"http://localhost:8083"}}`,
+			},
+			expectedEndpoint: "http://localhost:8083",
+		},
+		{
+			desc: "HTTPS endpoint preferred",
+			replicaData: &sf.ReplicaItemBase{
+				Address: `{"Endpoints":{"a":"http://localhost:8081","b":"HTTPS://localhost:8443","c":"http://localhost:8082"}}`,
+			},
+			expectedEndpoint: "HTTPS://localhost:8443",
+		},
+		{
+			desc: "first endpoint name",
+			replicaData: &sf.ReplicaItemBase{
+				Address: `{"Endpoints":{"z":"http://localhost:8083","b":"http://localhost:8082","m":"http://localhost:8081"}}`,
+			},
+			expectedEndpoint: "http://localhost:8082",
+		},
+		{
+			desc: "preferred endpoint name",
+			replicaData: &sf.ReplicaItemBase{
+				Address: `{"Endpoints":{"api":"http://localhost:8081","admin":"https://localhost:8443"}}`,
+			},
+			preferredNames:   []string{"missing", "api"},
+			expectedEndpoint: "http://localhost:8081",
+		},
+		{
+			desc: "preferred endpoint name without HTTP",
+			replicaData: &sf.ReplicaItemBase{
+				Address: `{"Endpoints":{"api":"tcp://localhost:8081","admin":"http://localhost:8082"}}`,
+			},
+			preferredNames:   []string{"api"},
+			expectedEndpoint: "http://localhost:8082",
+		},
 	}
 
 	for _, test := range testCases {
@@ -315,7 +687,7 @@ func TestGetReplicaDefaultEndpoint(t *testing.T) {
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			defaultEndpoint, err := getReplicaDefaultEndpoint(test.replicaData)
+			defaultEndpoint, err := getReplicaDefaultEndpoint(test.replicaData, test.preferredNames)
 			if test.errorExpected {
 				require.Error(t, err)
 			} else {
@@ -327,6 +699,59 @@ func TestGetReplicaDefaultEndpoint(t *testing.T) {
 	}
 }
 
+func TestCheckReplica(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		replicaData   *sf.ReplicaItemBase
+		expectedError string
+	}{
+		{
+			desc: "valid replica",
+			replicaData: &sf.ReplicaItemBase{
+				Address:       `{"Endpoints":{"":"http://localhost:8081"}}`,
+				HealthState:   "Ok",
+				ReplicaStatus: "Ready",
+			},
+		},
+		{
+			desc:          "missing replica data",
+			expectedError: "missing replica data",
+		},
+		{
+			desc: "unhealthy replica",
+			replicaData: &sf.ReplicaItemBase{
+				Address:       `{"Endpoints":{"":"http://localhost:8081"}}`,
+				HealthState:   "Error",
+				ReplicaStatus: "Ready",
+			},
+			expectedError: "replica status Ready with health state Error",
+		},
+		{
+			desc: "without HTTP endpoint",
+			replicaData: &sf.ReplicaItemBase{
+				Address:       `{"Endpoints":{"a":"tcp://httpbin:80","b":"localhost:8082"}}`,
+				HealthState:   "Ok",
+				ReplicaStatus: "Ready",
+			},
+			expectedError: `no HTTP endpoint found: endpoint "a": unsupported scheme "tcp", endpoint "b": unsupported scheme "localhost"`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := (&Provider{}).checkReplica(test.replicaData, nil, nodeFilter{})
+			if test.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.expectedError)
+			}
+		})
+	}
+}
+
 func TestGetReplicaNamedEndpoint(t *testing.T) {
 	testCases := []struct {
 		desc             string
@@ -348,6 +773,13 @@ func TestGetReplicaNamedEndpoint(t *testing.T) {
 			},
 			errorExpected: true,
 		},
+		{
+			desc: "non HTTP named endpoint",
+			replicaData: &sf.ReplicaItemBase{
+				Address: `{"Endpoints":{"DefaultEndpoint":"tcp://httpbin:80"}}`,
+			},
+			errorExpected: true,
+		},
 	}
 
 	for _, test := range testCases {
@@ -379,7 +811,8 @@ func TestGetClusterServices(t *testing.T) {
 		},
 	}
 
-	serviceItems, err := getClusterServices(client)
+	provider := Provider{}
+	serviceItems, err := provider.getClusterServices(&clusterConnection{client: client})
 	require.NoError(t, err)
 
 	expected := []ServiceItemExtended{
@@ -453,3 +886,205 @@ func TestGetClusterServices(t *testing.T) {
 
 	assert.Equal(t, expected, serviceItems)
 }
+
+func TestGetClusterServicesPaginated(t *testing.T) {
+	secondApp := apps.Items[0]
+	secondApp.ID = "SecondApplication"
+	secondApp.Name = "fabric:/SecondApplication"
+
+	secondService := services.Items[0]
+	secondService.ID = "TestApplication/SecondService"
+	secondService.Name = "fabric:/TestApplication/SecondService"
+
+	secondPartition := partitions.Items[0]
+	secondPartition.PartitionInformation.ID = "5e1a0d76-1c5c-4c4a-9b6b-6c6a3d0c8f2a"
+
+	secondInstance := instances.Items[0]
+	secondInstance.ID = "3"
+
+	client := &clientMock{
+		applications: &sf.ApplicationItemsPage{
+			ContinuationToken: stringPtr("apps-2"),
+			Items:             apps.Items,
+		},
+		applicationPages: map[string]*sf.ApplicationItemsPage{
+			"apps-2": {Items: []sf.ApplicationItem{secondApp}},
+		},
+		services: &sf.ServiceItemsPage{
+			ContinuationToken: stringPtr("services-2"),
+			Items:             services.Items,
+		},
+		servicePages: map[string]*sf.ServiceItemsPage{
+			"services-2": {Items: []sf.ServiceItem{secondService}},
+		},
+		partitions: &sf.PartitionItemsPage{
+			ContinuationToken: stringPtr("partitions-2"),
+			Items:             partitions.Items,
+		},
+		partitionPages: map[string]*sf.PartitionItemsPage{
+			"partitions-2": {Items: []sf.PartitionItem{secondPartition}},
+		},
+		instances: &sf.InstanceItemsPage{
+			ContinuationToken: stringPtr("instances-2"),
+			Items:             instances.Items,
+		},
+		instancePages: map[string]*sf.InstanceItemsPage{
+			"instances-2": {
+				ContinuationToken: stringPtr("instances-3"),
+				Items:             []sf.InstanceItem{secondInstance},
+			},
+			"instances-3": {Items: []sf.InstanceItem{}},
+		},
+		getServiceExtensionMapResult: map[string]string{
+			label.TraefikEnable: "true",
+		},
+	}
+
+	provider := Provider{}
+	serviceItems, err := provider.getClusterServices(&clusterConnection{client: client})
+	require.NoError(t, err)
+
+	var names []string
+	for _, item := range serviceItems {
+		names = append(names, item.Application.ID+" "+item.Name)
+
+		require.Len(t, item.Partitions, 2)
+		for _, partition := range item.Partitions {
+			require.Len(t, partition.Instances, 2)
+			assert.Equal(t, "1", partition.Instances[0].ID)
+			assert.Equal(t, "3", partition.Instances[1].ID)
+		}
+	}
+
+	expected := []string{
+		"TestApplication fabric:/TestApplication/TestService",
+		"TestApplication fabric:/TestApplication/SecondService",
+		"SecondApplication fabric:/TestApplication/TestService",
+		"SecondApplication fabric:/TestApplication/SecondService",
+	}
+	assert.Equal(t, expected, names)
+}
+
+func TestGetClusterServicesRepeatedContinuationToken(t *testing.T) {
+	client := &clientMock{
+		applications: &sf.ApplicationItemsPage{
+			ContinuationToken: stringPtr("apps-2"),
+			Items:             apps.Items,
+		},
+		applicationPages: map[string]*sf.ApplicationItemsPage{
+			"apps-2": {
+				ContinuationToken: stringPtr("apps-2"),
+				Items:             apps.Items,
+			},
+		},
+	}
+
+	provider := Provider{}
+	_, err := provider.getClusterServices(&clusterConnection{client: client})
+	require.Error(t, err)
+}
+
+func TestGetClusterServicesCollectsApplicationErrors(t *testing.T) {
+	failingApp := apps.Items[0]
+	failingApp.ID = "FailingApplication"
+	failingApp.Name = "fabric:/FailingApplication"
+
+	client := &clientMock{
+		applications: &sf.ApplicationItemsPage{
+			Items: []sf.ApplicationItem{failingApp, apps.Items[0]},
+		},
+		services:   services,
+		partitions: partitions,
+		instances:  instances,
+		servicesErrors: map[string]error{
+			failingApp.ID: errors.New("services unavailable"),
+		},
+		getServiceExtensionMapResult: map[string]string{
+			label.TraefikEnable: "true",
+		},
+	}
+
+	provider := Provider{DiscoveryWorkers: 2}
+	serviceItems, err := provider.getClusterServices(&clusterConnection{client: client})
+	require.Error(t, err)
+
+	var discoveryErr discoveryError
+	require.True(t, errors.As(err, &discoveryErr))
+	assert.Contains(t, discoveryErr, failingApp.ID)
+	assert.Len(t, discoveryErr, 1)
+
+	require.Len(t, serviceItems, 1)
+	assert.Equal(t, apps.Items[0].ID, serviceItems[0].Application.ID)
+}
+
+func TestGetClusterServicesBoundedConcurrency(t *testing.T) {
+	var appItems []sf.ApplicationItem
+	for i := 0; i < 10; i++ {
+		app := apps.Items[0]
+		app.ID = fmt.Sprintf("Application%d", i)
+		appItems = append(appItems, app)
+	}
+
+	client := &concurrencyClientMock{
+		clientMock: &clientMock{
+			applications: &sf.ApplicationItemsPage{Items: appItems},
+			services:     services,
+			partitions:   partitions,
+			instances:    instances,
+			getServiceExtensionMapResult: map[string]string{
+				label.TraefikEnable: "true",
+			},
+		},
+	}
+
+	provider := Provider{DiscoveryWorkers: 3}
+	serviceItems, err := provider.getClusterServices(&clusterConnection{client: client})
+	require.NoError(t, err)
+
+	require.Len(t, serviceItems, len(appItems))
+	for i, item := range serviceItems {
+		assert.Equal(t, appItems[i].ID, item.Application.ID)
+	}
+
+	maxInFlight := atomic.LoadInt32(&client.maxInFlight)
+	assert.True(t, maxInFlight <= 3, "too many concurrent requests: %d", maxInFlight)
+	assert.True(t, maxInFlight > 1, "requests were not run concurrently")
+}
+
+// concurrencyClientMock records the highest number of concurrent list requests.
+type concurrencyClientMock struct {
+	*clientMock
+	inFlight    int32
+	maxInFlight int32
+}
+
+func (c *concurrencyClientMock) track() func() {
+	current := atomic.AddInt32(&c.inFlight, 1)
+	for {
+		highest := atomic.LoadInt32(&c.maxInFlight)
+		if current <= highest || atomic.CompareAndSwapInt32(&c.maxInFlight, highest, current) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return func() { atomic.AddInt32(&c.inFlight, -1) }
+}
+
+func (c *concurrencyClientMock) GetServices(appName, continuationToken string) (*sf.ServiceItemsPage, error) {
+	defer c.track()()
+	return c.clientMock.GetServices(appName, continuationToken)
+}
+
+func (c *concurrencyClientMock) GetPartitions(appName, serviceName, continuationToken string) (*partitionItemsPage, error) {
+	defer c.track()()
+	return c.clientMock.GetPartitions(appName, serviceName, continuationToken)
+}
+
+func (c *concurrencyClientMock) GetInstances(appName, serviceName, partitionName, continuationToken string) (*sf.InstanceItemsPage, error) {
+	defer c.track()()
+	return c.clientMock.GetInstances(appName, serviceName, partitionName, continuationToken)
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
@@ -0,0 +1,308 @@
+package servicefabric
+
+import (
+	"bytes"
+	"crypto/sha1" //nolint:gosec // SHA-1 thumbprints are how Service Fabric identifies certificates.
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/traefik/traefik/log"
+	"github.com/traefik/traefik/types"
+)
+
+// ServerCertificate holds the settings used to pin the cluster server
+// certificate, Service Fabric cluster certificates are usually self-signed.
+type ServerCertificate struct {
+	Thumbprints       []string `description:"Accepted SHA-1 or SHA-256 thumbprints of the server certificate"`
+	CommonNames       []string `description:"Accepted common names of the server certificate"`
+	IssuerThumbprints []string `description:"Accepted thumbprints of the server certificate issuer, used with common names"`
+}
+
+// createTLSConfig builds the TLS configuration used to reach the cluster.
+// It returns nil when neither client TLS nor server pinning are configured.
+func createTLSConfig(clientTLS *types.ClientTLS, serverCert *ServerCertificate) (*tls.Config, error) {
+	var tlsConfig *tls.Config
+	if clientTLS != nil {
+		var err error
+		tlsConfig, err = clientTLS.CreateTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if serverCert != nil {
+		// Without a CA the chain is validated against the system roots.
+		var roots *x509.CertPool
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		} else if clientTLS.CA != "" {
+			roots = tlsConfig.RootCAs
+		}
+
+		if err := pinServerCertificate(tlsConfig, serverCert, roots); err != nil {
+			return nil, err
+		}
+	}
+	return tlsConfig, nil
+}
+
+// serverCertificateVerifier checks the server certificate against
+// the pinned thumbprints and common names.
+type serverCertificateVerifier struct {
+	thumbprints       [][]byte
+	commonNames       map[string]struct{}
+	issuerThumbprints [][]byte
+	roots             *x509.CertPool
+}
+
+// pinServerCertificate replaces the chain validation of tlsConfig
+// with the pinning rules of serverCert.
+func pinServerCertificate(tlsConfig *tls.Config, serverCert *ServerCertificate, roots *x509.CertPool) error {
+	verifier, err := newServerCertificateVerifier(serverCert, roots)
+	if err != nil {
+		return err
+	}
+
+	// The chain is verified by VerifyPeerCertificate instead.
+	tlsConfig.InsecureSkipVerify = true //nolint:gosec
+	tlsConfig.VerifyPeerCertificate = verifier.verify
+	return nil
+}
+
+func newServerCertificateVerifier(serverCert *ServerCertificate, roots *x509.CertPool) (*serverCertificateVerifier, error) {
+	if len(serverCert.Thumbprints) == 0 && len(serverCert.CommonNames) == 0 {
+		return nil, errors.New("server certificate thumbprints or common names must be set")
+	}
+
+	thumbprints, err := parseThumbprints(serverCert.Thumbprints)
+	if err != nil {
+		return nil, err
+	}
+
+	issuerThumbprints, err := parseThumbprints(serverCert.IssuerThumbprints)
+	if err != nil {
+		return nil, err
+	}
+
+	commonNames := make(map[string]struct{}, len(serverCert.CommonNames))
+	for _, name := range serverCert.CommonNames {
+		commonNames[strings.ToLower(strings.TrimSpace(name))] = struct{}{}
+	}
+
+	return &serverCertificateVerifier{
+		thumbprints:       thumbprints,
+		commonNames:       commonNames,
+		issuerThumbprints: issuerThumbprints,
+		roots:             roots,
+	}, nil
+}
+
+func (v *serverCertificateVerifier) verify(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return errors.New("no server certificate presented")
+	}
+
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("unable to parse server certificate: %w", err)
+		}
+		certs[i] = cert
+	}
+
+	leaf := certs[0]
+	if matchThumbprint(leaf, v.thumbprints) {
+		return nil
+	}
+
+	if _, ok := v.commonNames[strings.ToLower(leaf.Subject.CommonName)]; !ok {
+		return fmt.Errorf("server certificate %q does not match any pinned thumbprint or common name", leaf.Subject.CommonName)
+	}
+
+	if len(v.issuerThumbprints) == 0 {
+		return v.verifyChain(certs)
+	}
+	return v.verifyIssuer(certs)
+}
+
+// verifyIssuer accepts the leaf when it is valid at the current time and
+// signed by a presented certificate matching one of the issuer thumbprints.
+func (v *serverCertificateVerifier) verifyIssuer(certs []*x509.Certificate) error {
+	leaf := certs[0]
+	if now := time.Now(); now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return fmt.Errorf("server certificate %q is not valid at %s, it is valid from %s to %s",
+			leaf.Subject.CommonName, now.Format(time.RFC3339), leaf.NotBefore.Format(time.RFC3339), leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	for _, issuer := range certs[1:] {
+		if !matchThumbprint(issuer, v.issuerThumbprints) {
+			continue
+		}
+		if err := leaf.CheckSignatureFrom(issuer); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("server certificate %q is not issued by a pinned issuer", leaf.Subject.CommonName)
+}
+
+// verifyChain validates the chain against the root CAs, the host name is
+// not checked because the common name has been matched already.
+func (v *serverCertificateVerifier) verifyChain(certs []*x509.Certificate) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+func matchThumbprint(cert *x509.Certificate, thumbprints [][]byte) bool {
+	sha1Sum := sha1.Sum(cert.Raw) //nolint:gosec
+	sha256Sum := sha256.Sum256(cert.Raw)
+
+	for _, thumbprint := range thumbprints {
+		if bytes.Equal(thumbprint, sha1Sum[:]) || bytes.Equal(thumbprint, sha256Sum[:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseThumbprints decodes hexadecimal thumbprints,
+// spaces and colons are ignored.
+func parseThumbprints(values []string) ([][]byte, error) {
+	cleaner := strings.NewReplacer(" ", "", ":", "")
+
+	var thumbprints [][]byte
+	for _, value := range values {
+		thumbprint, err := hex.DecodeString(cleaner.Replace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid thumbprint %q: %w", value, err)
+		}
+		if len(thumbprint) != sha1.Size && len(thumbprint) != sha256.Size {
+			return nil, fmt.Errorf("invalid thumbprint %q: neither SHA-1 nor SHA-256", value)
+		}
+		thumbprints = append(thumbprints, thumbprint)
+	}
+	return thumbprints, nil
+}
+
+// certificateWatcher rebuilds the TLS configuration when the
+// client certificate files change on disk.
+type certificateWatcher struct {
+	clientTLS  *types.ClientTLS
+	serverCert *ServerCertificate
+	transport  *reloadableTransport
+	stamps     map[string]fileStamp
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func (s fileStamp) equal(other fileStamp) bool {
+	return s.modTime.Equal(other.modTime) && s.size == other.size
+}
+
+// newCertificateWatcher returns nil when none of the
+// certificate, key and CA are read from files.
+func newCertificateWatcher(clientTLS *types.ClientTLS, serverCert *ServerCertificate, transport *reloadableTransport) *certificateWatcher {
+	if clientTLS == nil {
+		return nil
+	}
+
+	stamps := make(map[string]fileStamp)
+	for _, path := range []string{clientTLS.Cert, clientTLS.Key, clientTLS.CA} {
+		if path == "" {
+			continue
+		}
+		// Inline PEM content is not a file.
+		if stamp, err := statFile(path); err == nil {
+			stamps[path] = stamp
+		}
+	}
+
+	if len(stamps) == 0 {
+		return nil
+	}
+
+	return &certificateWatcher{
+		clientTLS:  clientTLS,
+		serverCert: serverCert,
+		transport:  transport,
+		stamps:     stamps,
+	}
+}
+
+func (w *certificateWatcher) watch(stop chan bool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			w.reload()
+		}
+	}
+}
+
+// reload swaps the TLS configuration into the transport when one of
+// the files changed, and reports whether it did.
+// A failed reload keeps the previous configuration.
+func (w *certificateWatcher) reload() bool {
+	var changed []string
+	for path, stamp := range w.stamps {
+		current, err := statFile(path)
+		if err != nil {
+			log.Errorf("Unable to check TLS file %s: %v", path, err)
+			continue
+		}
+
+		if !current.equal(stamp) {
+			w.stamps[path] = current
+			changed = append(changed, path)
+		}
+	}
+
+	if len(changed) == 0 {
+		return false
+	}
+	sort.Strings(changed)
+
+	tlsConfig, err := createTLSConfig(w.clientTLS, w.serverCert)
+	if err != nil {
+		log.Errorf("Unable to reload TLS configuration after %s changed: %v", strings.Join(changed, ", "), err)
+		return false
+	}
+
+	w.transport.swap(tlsConfig)
+	log.Infof("Reloaded TLS configuration after %s changed", strings.Join(changed, ", "))
+	return true
+}
+
+func statFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	if info.IsDir() {
+		return fileStamp{}, fmt.Errorf("%s is a directory", path)
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
@@ -0,0 +1,340 @@
+package servicefabric
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/types"
+)
+
+func TestServerCertificateVerifier(t *testing.T) {
+	issuer, issuerKey := createTestCertificate(t, "cluster-issuer", nil, nil)
+	leaf, _ := createTestCertificate(t, "cluster.example.com", issuer, issuerKey)
+	otherIssuer, otherIssuerKey := createTestCertificate(t, "other-issuer", nil, nil)
+	foreignLeaf, _ := createTestCertificate(t, "cluster.example.com", otherIssuer, otherIssuerKey)
+	expiredLeaf, _ := createTestCertificateValidFor(t, "cluster.example.com", issuer, issuerKey, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour))
+	futureLeaf, _ := createTestCertificateValidFor(t, "cluster.example.com", issuer, issuerKey, time.Now().Add(time.Hour), time.Now().Add(2*time.Hour))
+
+	testCases := []struct {
+		desc          string
+		serverCert    ServerCertificate
+		rawCerts      [][]byte
+		errorExpected bool
+	}{
+		{
+			desc:       "SHA-1 thumbprint",
+			serverCert: ServerCertificate{Thumbprints: []string{sha1Thumbprint(leaf)}},
+			rawCerts:   [][]byte{leaf.Raw},
+		},
+		{
+			desc:       "SHA-256 thumbprint with colons",
+			serverCert: ServerCertificate{Thumbprints: []string{colonSeparated(sha256Thumbprint(leaf))}},
+			rawCerts:   [][]byte{leaf.Raw},
+		},
+		{
+			desc:          "unknown thumbprint",
+			serverCert:    ServerCertificate{Thumbprints: []string{sha1Thumbprint(issuer)}},
+			rawCerts:      [][]byte{leaf.Raw},
+			errorExpected: true,
+		},
+		{
+			desc: "common name and issuer thumbprint",
+			serverCert: ServerCertificate{
+				CommonNames:       []string{"Cluster.Example.com"},
+				IssuerThumbprints: []string{sha1Thumbprint(issuer)},
+			},
+			rawCerts: [][]byte{leaf.Raw, issuer.Raw},
+		},
+		{
+			desc: "common name with wrong issuer",
+			serverCert: ServerCertificate{
+				CommonNames:       []string{"cluster.example.com"},
+				IssuerThumbprints: []string{sha1Thumbprint(issuer)},
+			},
+			rawCerts:      [][]byte{foreignLeaf.Raw, otherIssuer.Raw},
+			errorExpected: true,
+		},
+		{
+			desc: "common name with pinned issuer not signing the leaf",
+			serverCert: ServerCertificate{
+				CommonNames:       []string{"cluster.example.com"},
+				IssuerThumbprints: []string{sha1Thumbprint(issuer)},
+			},
+			rawCerts:      [][]byte{foreignLeaf.Raw, issuer.Raw},
+			errorExpected: true,
+		},
+		{
+			desc: "common name and issuer thumbprint with expired leaf",
+			serverCert: ServerCertificate{
+				CommonNames:       []string{"cluster.example.com"},
+				IssuerThumbprints: []string{sha1Thumbprint(issuer)},
+			},
+			rawCerts:      [][]byte{expiredLeaf.Raw, issuer.Raw},
+			errorExpected: true,
+		},
+		{
+			desc: "common name and issuer thumbprint with leaf not yet valid",
+			serverCert: ServerCertificate{
+				CommonNames:       []string{"cluster.example.com"},
+				IssuerThumbprints: []string{sha1Thumbprint(issuer)},
+			},
+			rawCerts:      [][]byte{futureLeaf.Raw, issuer.Raw},
+			errorExpected: true,
+		},
+		{
+			desc: "unknown common name",
+			serverCert: ServerCertificate{
+				CommonNames:       []string{"other.example.com"},
+				IssuerThumbprints: []string{sha1Thumbprint(issuer)},
+			},
+			rawCerts:      [][]byte{leaf.Raw, issuer.Raw},
+			errorExpected: true,
+		},
+		{
+			desc:          "no certificate",
+			serverCert:    ServerCertificate{Thumbprints: []string{sha1Thumbprint(leaf)}},
+			errorExpected: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			verifier, err := newServerCertificateVerifier(&test.serverCert, nil)
+			require.NoError(t, err)
+
+			err = verifier.verify(test.rawCerts, nil)
+			if test.errorExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewServerCertificateVerifierInvalidConfig(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		serverCert ServerCertificate
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc:       "not hexadecimal",
+			serverCert: ServerCertificate{Thumbprints: []string{"not-a-thumbprint"}},
+		},
+		{
+			desc:       "wrong length",
+			serverCert: ServerCertificate{Thumbprints: []string{"abcdef"}},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := newServerCertificateVerifier(&test.serverCert, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestClusterClientPinnedServerCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"Items":[{"Id":"app1"}]}`)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		desc          string
+		thumbprint    string
+		errorExpected bool
+	}{
+		{
+			desc:       "pinned thumbprint",
+			thumbprint: sha256Thumbprint(server.Certificate()),
+		},
+		{
+			desc:          "other thumbprint",
+			thumbprint:    strings.Repeat("00", sha256.Size),
+			errorExpected: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			tlsConfig, err := createTLSConfig(nil, &ServerCertificate{Thumbprints: []string{test.thumbprint}})
+			require.NoError(t, err)
+
+			httpClient, err := newHTTPClient(newTLSTransport(tlsConfig), nil)
+			require.NoError(t, err)
+
+			client, err := newClusterClient(httpClient, server.URL, "")
+			require.NoError(t, err)
+
+			_, err = listApplications(client)
+			if test.errorExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCertificateWatcherReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "servicefabric-tls")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	clientTLS := &types.ClientTLS{
+		Cert: filepath.Join(dir, "client.crt"),
+		Key:  filepath.Join(dir, "client.key"),
+	}
+
+	firstCert, firstKey := createTestCertificate(t, "client-1", nil, nil)
+	writeTestKeyPair(t, clientTLS, firstCert, firstKey, time.Now().Add(-time.Minute))
+
+	tlsConfig, err := createTLSConfig(clientTLS, nil)
+	require.NoError(t, err)
+
+	transport := newReloadableTransport(tlsConfig)
+	watcher := newCertificateWatcher(clientTLS, nil, transport)
+	require.NotNil(t, watcher)
+
+	assert.False(t, watcher.reload(), "nothing changed")
+	assert.Equal(t, firstCert.Raw, currentClientCertificate(transport))
+
+	secondCert, secondKey := createTestCertificate(t, "client-2", nil, nil)
+	writeTestKeyPair(t, clientTLS, secondCert, secondKey, time.Now())
+
+	assert.True(t, watcher.reload())
+	assert.Equal(t, secondCert.Raw, currentClientCertificate(transport))
+
+	// A broken key keeps the previous configuration.
+	require.NoError(t, ioutil.WriteFile(clientTLS.Key, []byte("not a key"), 0o600))
+	require.NoError(t, os.Chtimes(clientTLS.Key, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+
+	assert.False(t, watcher.reload())
+	assert.Equal(t, secondCert.Raw, currentClientCertificate(transport))
+}
+
+func TestNewCertificateWatcherInlineCertificate(t *testing.T) {
+	cert, key := createTestCertificate(t, "client", nil, nil)
+	certPEM, keyPEM := encodeTestKeyPair(t, cert, key)
+
+	clientTLS := &types.ClientTLS{
+		Cert: string(certPEM),
+		Key:  string(keyPEM),
+	}
+
+	assert.Nil(t, newCertificateWatcher(clientTLS, nil, newReloadableTransport(nil)))
+	assert.Nil(t, newCertificateWatcher(nil, nil, newReloadableTransport(nil)))
+}
+
+func currentClientCertificate(transport *reloadableTransport) []byte {
+	transport.lock.RLock()
+	defer transport.lock.RUnlock()
+
+	return transport.transport.TLSClientConfig.Certificates[0].Certificate[0]
+}
+
+func writeTestKeyPair(t *testing.T, clientTLS *types.ClientTLS, cert *x509.Certificate, key *ecdsa.PrivateKey, modTime time.Time) {
+	t.Helper()
+
+	certPEM, keyPEM := encodeTestKeyPair(t, cert, key)
+	require.NoError(t, ioutil.WriteFile(clientTLS.Cert, certPEM, 0o600))
+	require.NoError(t, ioutil.WriteFile(clientTLS.Key, keyPEM, 0o600))
+
+	require.NoError(t, os.Chtimes(clientTLS.Cert, modTime, modTime))
+	require.NoError(t, os.Chtimes(clientTLS.Key, modTime, modTime))
+}
+
+func encodeTestKeyPair(t *testing.T, cert *x509.Certificate, key *ecdsa.PrivateKey) ([]byte, []byte) {
+	t.Helper()
+
+	rawKey, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: rawKey})
+	return certPEM, keyPEM
+}
+
+func createTestCertificate(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	return createTestCertificateValidFor(t, commonName, parent, parentKey, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+}
+
+func createTestCertificateValidFor(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, notBefore, notAfter time.Time) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+
+	if parent == nil {
+		parent = template
+		parentKey = key
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func sha1Thumbprint(cert *x509.Certificate) string {
+	sum := sha1.Sum(cert.Raw) //nolint:gosec
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func sha256Thumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func colonSeparated(thumbprint string) string {
+	var parts []string
+	for i := 0; i < len(thumbprint); i += 2 {
+		parts = append(parts, thumbprint[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
@@ -2,20 +2,35 @@ package servicefabric
 
 const tmpl = `
 [backends]
-{{range $aggName, $aggServices := getGroupedServices .Services }}
-  [backends."{{ $aggName }}"]
-  {{range $service := $aggServices }}
+{{range $group := .Groups }}
+  {{ $groupName := $group.Name }}
+  [backends."{{ $groupName }}"]
+  {{template "backendOptions" dict "service" $group.Service "backendName" $groupName "loadBalancer" (getLoadBalancer $group.Service) }}
+
+  {{range $service := $group.Members }}
   {{range $partition := $service.Partitions }}
   {{range $instance := $partition.Instances }}
-    [backends."{{ $aggName }}".servers."{{ $service.ID }}-{{ $instance.ID }}"]
-      weight = {{ getGroupedWeight $service }}
-      {{ $endpointName := getLabelValue $service "traefik.servicefabric.endpointname" "" }}
+    [backends."{{ $groupName }}".servers."{{ getServiceID $service }}-{{ $instance.ID }}"]
+      weight = {{ getNodeWeight $partition $instance (getGroupedWeight $service) }}
+      {{ $endpointName := getEndpointName $service }}
       {{if $endpointName }}
         url = "{{ getNamedEndpoint $instance $endpointName }}"
       {{else}}
         url = "{{ getDefaultEndpoint $instance }}"
       {{end}}
   {{end}}
+  {{range $replica := $partition.Replicas }}
+  {{if isGroupReplica $service $replica }}
+    [backends."{{ $groupName }}".servers."{{ getServiceID $service }}-{{ $replica.ID }}"]
+      weight = {{ getNodeWeight $partition $replica (getGroupedWeight $service) }}
+      {{ $endpointName := getEndpointName $service }}
+      {{if $endpointName }}
+        url = "{{ getNamedEndpoint $replica $endpointName }}"
+      {{else}}
+        url = "{{ getDefaultEndpoint $replica }}"
+      {{end}}
+  {{end}}
+  {{end}}
   {{end}}
   {{end}}
 {{end}}
@@ -26,55 +41,15 @@ const tmpl = `
 
       {{if isStateless $service }}
 
-        {{ $backendName := $service.Name }}
+        {{ $backendName := getServiceName $service }}
         [backends."{{ $backendName }}"]
 
-        {{ $circuitBreaker := getCircuitBreaker $service }}
-        {{if $circuitBreaker }}
-          [backends."{{ $backendName }}".circuitBreaker]
-            expression = "{{ $circuitBreaker.Expression }}"
-        {{end}}
-
-        {{ $loadBalancer := getLoadBalancer $service }}
-        {{if $loadBalancer }}
-          [backends."{{ $backendName }}".loadBalancer]
-            method = "{{ $loadBalancer.Method }}"
-            sticky = {{ $loadBalancer.Sticky }}
-            {{if $loadBalancer.Stickiness }}
-            [backends."{{ $backendName }}".loadBalancer.stickiness]
-              cookieName = "{{ $loadBalancer.Stickiness.CookieName }}"
-              secure = {{ $loadBalancer.Stickiness.Secure }}
-              httpOnly = {{ $loadBalancer.Stickiness.HTTPOnly }}
-              sameSite = "{{ $loadBalancer.Stickiness.SameSite }}"
-            {{end}}
-        {{end}}
-
-        {{ $maxConn := getMaxConn $service }}
-        {{if $maxConn }}
-          [backends."{{ $backendName }}".maxConn]
-            extractorFunc = "{{ $maxConn.ExtractorFunc }}"
-            amount = {{ $maxConn.Amount }}
-        {{end}}
-
-        {{ $healthCheck := getHealthCheck $service }}
-        {{if $healthCheck }}
-          [backends."{{ $backendName }}".healthCheck]
-            path = "{{ $healthCheck.Path }}"
-            port = {{ $healthCheck.Port }}
-            interval = "{{ $healthCheck.Interval }}"
-            hostname = "{{ $healthCheck.Hostname }}"
-            {{if $healthCheck.Headers }}
-            [backends."{{ $backendName }}".healthCheck.headers]
-              {{range $k, $v := $healthCheck.Headers }}
-              {{$k}} = "{{$v}}"
-              {{end}}
-            {{end}}
-        {{end}}
+        {{template "backendOptions" dict "service" $service "backendName" $backendName "loadBalancer" (getLoadBalancer $service) }}
 
         {{range $instance := $partition.Instances}}
-          [backends."{{ $service.Name }}".servers."{{ $instance.ID }}"]
-            weight = {{ getWeight $service }}
-            {{ $endpointName := getLabelValue $service "traefik.servicefabric.endpointname" "" }}
+          [backends."{{ $backendName }}".servers."{{ $instance.ID }}"]
+            weight = {{ getNodeWeight $partition $instance (getWeight $service) }}
+            {{ $endpointName := getEndpointName $service }}
             {{if $endpointName }}
               url = "{{ getNamedEndpoint $instance $endpointName }}"
             {{else}}
@@ -87,18 +62,36 @@ const tmpl = `
         {{range $replica := $partition.Replicas}}
           {{if isPrimary $replica}}
             {{ $backendName := getBackendName $service $partition }}
+            [backends."{{ $backendName }}"]
+            {{template "backendOptions" dict "service" $service "backendName" $backendName "loadBalancer" (getPartitionLoadBalancer $service) }}
+
             [backends."{{ $backendName }}".servers."{{ $replica.ID }}"]
-              weight = 1
-              {{ $endpointName := getLabelValue $service "traefik.servicefabric.endpointname" "" }}
+              weight = {{ getNodeWeight $partition $replica (getWeight $service) }}
+              {{ $endpointName := getEndpointName $service }}
               {{if $endpointName }}
                 url = "{{ getNamedEndpoint $replica $endpointName }}"
               {{else}}
                 url = "{{ getDefaultEndpoint $replica }}"
               {{end}}
+          {{end}}
+        {{end}}
 
-              [backends."{{$backendName}}".LoadBalancer]
-                method = "drr"
+        {{if hasReadSecondaries $service $partition }}
+          {{ $secondariesBackendName := getSecondariesBackendName $service $partition }}
+          [backends."{{ $secondariesBackendName }}"]
+          {{template "backendOptions" dict "service" $service "backendName" $secondariesBackendName "loadBalancer" (getPartitionLoadBalancer $service) }}
 
+          {{range $replica := $partition.Replicas}}
+            {{if isActiveSecondary $replica}}
+            [backends."{{ $secondariesBackendName }}".servers."{{ $replica.ID }}"]
+              weight = {{ getNodeWeight $partition $replica (getWeight $service) }}
+              {{ $endpointName := getEndpointName $service }}
+              {{if $endpointName }}
+                url = "{{ getNamedEndpoint $replica $endpointName }}"
+              {{else}}
+                url = "{{ getDefaultEndpoint $replica }}"
+              {{end}}
+            {{end}}
           {{end}}
         {{end}}
 
@@ -109,128 +102,27 @@ const tmpl = `
 {{end}}
 
 [frontends]
-{{range $groupName, $groupServices := getGroupedServices .Services }}
-  {{ $service := index $groupServices 0 }}
+{{range $group := .Groups }}
+  {{ $groupName := $group.Name }}
   [frontends."{{ $groupName }}"]
     backend = "{{ $groupName }}"
-    priority = 50
+    {{template "frontendOptions" dict "service" $group.Service "frontendName" $groupName "priority" (getGroupPriority $group) }}
 
-  {{range $key, $value := getFrontendRules $service }}
+  {{range $key, $value := getFrontendRules $group.Service }}
     [frontends."{{ $groupName }}".routes."{{ $key }}"]
       rule = "{{ $value }}"
   {{end}}
 {{end}}
 
 {{range $service := .Services }}
-  {{if isEnabled $service }}
-    {{ $frontendName := $service.Name }}
+  {{if and (isEnabled $service) (not (isGroupOnly $service)) }}
+    {{ $frontendName := getServiceName $service }}
 
     {{if isStateless $service }}
 
       [frontends."frontend-{{ $frontendName }}"]
-        backend = "{{ $service.Name }}"
-        passHostHeader = {{ getPassHostHeader $service }}
-        passTLSCert = {{ getPassTLSCert $service }}
-        priority = {{ getPriority $service }}
-
-        {{ $entryPoints := getEntryPoints $service }}
-        {{if $entryPoints }}
-        entryPoints = [{{range $entryPoints }}
-          "{{.}}",
-          {{end}}]
-        {{end}}
-
-        {{ $basicAuth := getBasicAuth $service }}
-        {{if $basicAuth }}
-         basicAuth = [{{range $basicAuth }}
-          "{{.}}",
-          {{end}}]
-        {{end}}
-
-        {{ $whitelist := getWhiteList $service }}
-        {{if $whitelist }}
-        [frontends."frontend-{{ $frontendName }}".whiteList]
-          sourceRange = [{{range $whitelist.SourceRange }}
-            "{{.}}",
-            {{end}}]
-          useXForwardedFor = {{ $whitelist.UseXForwardedFor }}
-        {{end}}
-
-        {{ $redirect := getRedirect $service }}
-        {{if $redirect }}
-        [frontends."frontend-{{ $frontendName }}".redirect]
-          entryPoint = "{{ $redirect.EntryPoint }}"
-          regex = "{{ $redirect.Regex }}"
-          replacement = "{{ $redirect.Replacement }}"
-          permanent = {{ $redirect.Permanent }}
-        {{end}}
-
-        {{ $errorPages := getErrorPages $service }}
-        {{if $errorPages }}
-        [frontends."frontend-{{ $frontendName }}".errors]
-          {{range $pageName, $page := $errorPages }}
-          [frontends."frontend-{{ $frontendName }}".errors."{{ $pageName }}"]
-            status = [{{range $page.Status }}
-              "{{.}}",
-              {{end}}]
-            backend = "{{ $page.Backend }}"
-            query = "{{ $page.Query }}"
-          {{end}}
-        {{end}}
-
-        {{ $headers := getHeaders $service }}
-        {{if $headers }}
-        [frontends."frontend-{{ $frontendName }}".headers]
-          SSLRedirect = {{ $headers.SSLRedirect }}
-          SSLTemporaryRedirect = {{ $headers.SSLTemporaryRedirect }}
-          SSLHost = "{{ $headers.SSLHost }}"
-          STSSeconds = {{ $headers.STSSeconds }}
-          STSIncludeSubdomains = {{ $headers.STSIncludeSubdomains }}
-          STSPreload = {{ $headers.STSPreload }}
-          ForceSTSHeader = {{ $headers.ForceSTSHeader }}
-          FrameDeny = {{ $headers.FrameDeny }}
-          CustomFrameOptionsValue = "{{ $headers.CustomFrameOptionsValue }}"
-          ContentTypeNosniff = {{ $headers.ContentTypeNosniff }}
-          BrowserXSSFilter = {{ $headers.BrowserXSSFilter }}
-          CustomBrowserXSSValue = "{{ $headers.CustomBrowserXSSValue }}"
-          ContentSecurityPolicy = "{{ $headers.ContentSecurityPolicy }}"
-          PublicKey = "{{ $headers.PublicKey }}"
-          ReferrerPolicy = "{{ $headers.ReferrerPolicy }}"
-          IsDevelopment = {{ $headers.IsDevelopment }}
-
-          {{if $headers.AllowedHosts }}
-          AllowedHosts = [{{range $headers.AllowedHosts }}
-            "{{.}}",
-            {{end}}]
-          {{end}}
-
-          {{if $headers.HostsProxyHeaders }}
-          HostsProxyHeaders = [{{range $headers.HostsProxyHeaders }}
-            "{{.}}",
-            {{end}}]
-          {{end}}
-
-          {{if $headers.CustomRequestHeaders }}
-          [frontends."frontend-{{ $frontendName }}".headers.customRequestHeaders]
-            {{range $k, $v := $headers.CustomRequestHeaders }}
-            {{$k}} = "{{$v}}"
-            {{end}}
-          {{end}}
-
-          {{if $headers.CustomResponseHeaders }}
-          [frontends."frontend-{{ $frontendName }}".headers.customResponseHeaders]
-            {{range $k, $v := $headers.CustomResponseHeaders }}
-            {{$k}} = "{{$v}}"
-            {{end}}
-          {{end}}
-
-          {{if $headers.SSLProxyHeaders }}
-          [frontends."frontend-{{ $frontendName }}".headers.SSLProxyHeaders]
-            {{range $k, $v := $headers.SSLProxyHeaders }}
-            {{$k}} = "{{$v}}"
-            {{end}}
-          {{end}}
-        {{end}}
+        backend = "{{ $frontendName }}"
+        {{template "frontendOptions" dict "service" $service "frontendName" (print "frontend-" $frontendName) "priority" (getPriority $service) }}
 
       {{range $key, $value := getFrontendRules $service }}
         [frontends."frontend-{{ $frontendName }}".routes."{{ $key }}"]
@@ -242,13 +134,28 @@ const tmpl = `
       {{range $partition := $service.Partitions }}
         {{ $partitionId := $partition.PartitionInformation.ID }}
 
-        {{ $rule := getLabelValue $service (print "traefik.frontend.rule.partition." $partitionId) "" }}
+        {{ $rule := getPartitionRule $service $partition }}
         {{if $rule }}
-        [frontends."{{ $service.Name }}/{{ $partitionId }}"]
+        {{ $partitionFrontendName := print $frontendName "/" $partitionId }}
+        [frontends."{{ $partitionFrontendName }}"]
           backend = "{{ getBackendName $service $partition }}"
+          {{template "frontendOptions" dict "service" $service "frontendName" $partitionFrontendName "priority" (getPriority $service) }}
+
+          [frontends."{{ $partitionFrontendName }}".routes.default]
+            rule = "{{ $rule }}"
+
+        {{if hasReadSecondaries $service $partition }}
+        {{ $secondariesFrontendName := print $partitionFrontendName "/secondaries" }}
+        [frontends."{{ $secondariesFrontendName }}"]
+          backend = "{{ getSecondariesBackendName $service $partition }}"
+          {{template "frontendOptions" dict "service" $service "frontendName" $secondariesFrontendName "priority" (getSecondariesPriority $service) }}
 
-          [frontends."{{ $service.Name }}/{{ $partitionId }}".routes.default]
+          [frontends."{{ $secondariesFrontendName }}".routes.default]
             rule = "{{ $rule }}"
+
+          [frontends."{{ $secondariesFrontendName }}".routes.secondaries]
+            rule = "{{ getSecondariesRule $service }}"
+        {{end}}
         {{end}}
       {{end}}
 
@@ -256,4 +163,158 @@ const tmpl = `
 
   {{end}}
 {{end}}
+
+{{define "backendOptions"}}
+  {{ $service := .service }}
+  {{ $backendName := .backendName }}
+
+  {{ $circuitBreaker := getCircuitBreaker $service }}
+  {{if $circuitBreaker }}
+    [backends."{{ $backendName }}".circuitBreaker]
+      expression = "{{ $circuitBreaker.Expression }}"
+  {{end}}
+
+  {{ $loadBalancer := .loadBalancer }}
+  {{if $loadBalancer }}
+    [backends."{{ $backendName }}".loadBalancer]
+      method = "{{ $loadBalancer.Method }}"
+      sticky = {{ $loadBalancer.Sticky }}
+      {{if $loadBalancer.Stickiness }}
+      [backends."{{ $backendName }}".loadBalancer.stickiness]
+        cookieName = "{{ $loadBalancer.Stickiness.CookieName }}"
+        secure = {{ $loadBalancer.Stickiness.Secure }}
+        httpOnly = {{ $loadBalancer.Stickiness.HTTPOnly }}
+        sameSite = "{{ $loadBalancer.Stickiness.SameSite }}"
+      {{end}}
+  {{end}}
+
+  {{ $maxConn := getMaxConn $service }}
+  {{if $maxConn }}
+    [backends."{{ $backendName }}".maxConn]
+      extractorFunc = "{{ $maxConn.ExtractorFunc }}"
+      amount = {{ $maxConn.Amount }}
+  {{end}}
+
+  {{ $healthCheck := getHealthCheck $service }}
+  {{if $healthCheck }}
+    [backends."{{ $backendName }}".healthCheck]
+      path = "{{ $healthCheck.Path }}"
+      port = {{ $healthCheck.Port }}
+      interval = "{{ $healthCheck.Interval }}"
+      hostname = "{{ $healthCheck.Hostname }}"
+      {{if $healthCheck.Headers }}
+      [backends."{{ $backendName }}".healthCheck.headers]
+        {{range $k, $v := $healthCheck.Headers }}
+        {{$k}} = "{{$v}}"
+        {{end}}
+      {{end}}
+  {{end}}
+{{end}}
+
+{{define "frontendOptions"}}
+  {{ $service := .service }}
+  {{ $frontendName := .frontendName }}
+  passHostHeader = {{ getPassHostHeader $service }}
+  passTLSCert = {{ getPassTLSCert $service }}
+  priority = {{ .priority }}
+
+  {{ $entryPoints := getEntryPoints $service }}
+  {{if $entryPoints }}
+  entryPoints = [{{range $entryPoints }}
+    "{{.}}",
+    {{end}}]
+  {{end}}
+
+  {{ $basicAuth := getBasicAuth $service }}
+  {{if $basicAuth }}
+   basicAuth = [{{range $basicAuth }}
+    "{{.}}",
+    {{end}}]
+  {{end}}
+
+  {{ $whitelist := getWhiteList $service }}
+  {{if $whitelist }}
+  [frontends."{{ $frontendName }}".whiteList]
+    sourceRange = [{{range $whitelist.SourceRange }}
+      "{{.}}",
+      {{end}}]
+    useXForwardedFor = {{ $whitelist.UseXForwardedFor }}
+  {{end}}
+
+  {{ $redirect := getRedirect $service }}
+  {{if $redirect }}
+  [frontends."{{ $frontendName }}".redirect]
+    entryPoint = "{{ $redirect.EntryPoint }}"
+    regex = "{{ $redirect.Regex }}"
+    replacement = "{{ $redirect.Replacement }}"
+    permanent = {{ $redirect.Permanent }}
+  {{end}}
+
+  {{ $errorPages := getErrorPages $service }}
+  {{if $errorPages }}
+  [frontends."{{ $frontendName }}".errors]
+    {{range $pageName, $page := $errorPages }}
+    [frontends."{{ $frontendName }}".errors."{{ $pageName }}"]
+      status = [{{range $page.Status }}
+        "{{.}}",
+        {{end}}]
+      backend = "{{ $page.Backend }}"
+      query = "{{ $page.Query }}"
+    {{end}}
+  {{end}}
+
+  {{ $headers := getHeaders $service }}
+  {{if $headers }}
+  [frontends."{{ $frontendName }}".headers]
+    SSLRedirect = {{ $headers.SSLRedirect }}
+    SSLTemporaryRedirect = {{ $headers.SSLTemporaryRedirect }}
+    SSLHost = "{{ $headers.SSLHost }}"
+    STSSeconds = {{ $headers.STSSeconds }}
+    STSIncludeSubdomains = {{ $headers.STSIncludeSubdomains }}
+    STSPreload = {{ $headers.STSPreload }}
+    ForceSTSHeader = {{ $headers.ForceSTSHeader }}
+    FrameDeny = {{ $headers.FrameDeny }}
+    CustomFrameOptionsValue = "{{ $headers.CustomFrameOptionsValue }}"
+    ContentTypeNosniff = {{ $headers.ContentTypeNosniff }}
+    BrowserXSSFilter = {{ $headers.BrowserXSSFilter }}
+    CustomBrowserXSSValue = "{{ $headers.CustomBrowserXSSValue }}"
+    ContentSecurityPolicy = "{{ $headers.ContentSecurityPolicy }}"
+    PublicKey = "{{ $headers.PublicKey }}"
+    ReferrerPolicy = "{{ $headers.ReferrerPolicy }}"
+    IsDevelopment = {{ $headers.IsDevelopment }}
+
+    {{if $headers.AllowedHosts }}
+    AllowedHosts = [{{range $headers.AllowedHosts }}
+      "{{.}}",
+      {{end}}]
+    {{end}}
+
+    {{if $headers.HostsProxyHeaders }}
+    HostsProxyHeaders = [{{range $headers.HostsProxyHeaders }}
+      "{{.}}",
+      {{end}}]
+    {{end}}
+
+    {{if $headers.CustomRequestHeaders }}
+    [frontends."{{ $frontendName }}".headers.customRequestHeaders]
+      {{range $k, $v := $headers.CustomRequestHeaders }}
+      {{$k}} = "{{$v}}"
+      {{end}}
+    {{end}}
+
+    {{if $headers.CustomResponseHeaders }}
+    [frontends."{{ $frontendName }}".headers.customResponseHeaders]
+      {{range $k, $v := $headers.CustomResponseHeaders }}
+      {{$k}} = "{{$v}}"
+      {{end}}
+    {{end}}
+
+    {{if $headers.SSLProxyHeaders }}
+    [frontends."{{ $frontendName }}".headers.SSLProxyHeaders]
+      {{range $k, $v := $headers.SSLProxyHeaders }}
+      {{$k}} = "{{$v}}"
+      {{end}}
+    {{end}}
+  {{end}}
+{{end}}
 `
@@ -0,0 +1,90 @@
+package servicefabric
+
+import (
+	"sort"
+
+	"github.com/mitchellh/hashstructure"
+	"github.com/traefik/traefik/log"
+	"github.com/traefik/traefik/types"
+)
+
+// topologyTracker remembers the last configuration sent by the provider
+// and the services it was built from.
+type topologyTracker struct {
+	configHash    uint64
+	serviceHashes map[string]uint64
+}
+
+// topologyDiff lists the names of the services which differ
+// between two discoveries.
+type topologyDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// update records the new configuration and reports whether it differs
+// from the previous one. Services are only compared when it does.
+func (t *topologyTracker) update(services []ServiceItemExtended, configuration *types.Configuration) (bool, topologyDiff) {
+	configHash, err := hashstructure.Hash(configuration, nil)
+	if err != nil {
+		log.Errorf("Unable to hash Service Fabric configuration: %v", err)
+		t.configHash = 0
+		t.serviceHashes = nil
+		return true, topologyDiff{}
+	}
+
+	if t.serviceHashes != nil && configHash == t.configHash {
+		return false, topologyDiff{}
+	}
+
+	serviceHashes := hashServices(services)
+	diff := diffServiceHashes(t.serviceHashes, serviceHashes)
+
+	t.configHash = configHash
+	t.serviceHashes = serviceHashes
+	return true, diff
+}
+
+func hashServices(services []ServiceItemExtended) map[string]uint64 {
+	hashes := make(map[string]uint64, len(services))
+	for _, service := range services {
+		hash, err := hashstructure.Hash(service, nil)
+		if err != nil {
+			log.Errorf("Unable to hash service %s: %v", getServiceName(service), err)
+		}
+		hashes[getServiceName(service)] = hash
+	}
+	return hashes
+}
+
+func diffServiceHashes(previous, current map[string]uint64) topologyDiff {
+	var diff topologyDiff
+	for name, hash := range current {
+		previousHash, exists := previous[name]
+		switch {
+		case !exists:
+			diff.Added = append(diff.Added, name)
+		case previousHash != hash:
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+
+	for name := range previous {
+		if _, exists := current[name]; !exists {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+func logTopologyDiff(diff topologyDiff) {
+	log.WithField("added", diff.Added).
+		WithField("removed", diff.Removed).
+		WithField("changed", diff.Changed).
+		Infof("Service Fabric topology changed: %d added, %d removed, %d changed", len(diff.Added), len(diff.Removed), len(diff.Changed))
+}
@@ -0,0 +1,129 @@
+package servicefabric
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sf "github.com/jjcollinge/servicefabric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/provider/label"
+	"github.com/traefik/traefik/safe"
+	"github.com/traefik/traefik/types"
+)
+
+func TestTopologyTrackerUpdate(t *testing.T) {
+	provider := Provider{}
+	tracker := &topologyTracker{}
+
+	newService := func(name, url string) ServiceItemExtended {
+		return ServiceItemExtended{
+			ServiceItem: sf.ServiceItem{
+				Name:        name,
+				ServiceKind: kindStateless,
+			},
+			Partitions: []PartitionItemExtended{
+				{
+					Instances: []sf.InstanceItem{
+						{
+							ReplicaItemBase: &sf.ReplicaItemBase{
+								Address:       `{"Endpoints":{"":"` + url + `"}}`,
+								HealthState:   "Ok",
+								ReplicaStatus: "Ready",
+							},
+							ID: "1",
+						},
+					},
+				},
+			},
+			Labels: map[string]string{
+				label.TraefikEnable: "true",
+			},
+		}
+	}
+
+	testCases := []struct {
+		desc            string
+		services        []ServiceItemExtended
+		expectedChanged bool
+		expectedDiff    topologyDiff
+	}{
+		{
+			desc: "first discovery",
+			services: []ServiceItemExtended{
+				newService("fabric:/App/A", "http://localhost:8081"),
+				newService("fabric:/App/B", "http://localhost:8082"),
+			},
+			expectedChanged: true,
+			expectedDiff: topologyDiff{
+				Added: []string{"fabric:/App/A", "fabric:/App/B"},
+			},
+		},
+		{
+			desc: "same topology",
+			services: []ServiceItemExtended{
+				newService("fabric:/App/A", "http://localhost:8081"),
+				newService("fabric:/App/B", "http://localhost:8082"),
+			},
+			expectedChanged: false,
+		},
+		{
+			desc: "service moved, added and removed",
+			services: []ServiceItemExtended{
+				newService("fabric:/App/A", "http://localhost:9091"),
+				newService("fabric:/App/C", "http://localhost:8083"),
+			},
+			expectedChanged: true,
+			expectedDiff: topologyDiff{
+				Added:   []string{"fabric:/App/C"},
+				Removed: []string{"fabric:/App/B"},
+				Changed: []string{"fabric:/App/A"},
+			},
+		},
+	}
+
+	// The cases are applied in sequence on the same tracker.
+	for _, test := range testCases {
+		configuration, err := provider.buildConfiguration(test.services)
+		require.NoError(t, err, test.desc)
+
+		changed, diff := tracker.update(test.services, configuration)
+		assert.Equal(t, test.expectedChanged, changed, test.desc)
+		assert.Equal(t, test.expectedDiff, diff, test.desc)
+	}
+}
+
+func TestUpdateConfigSendsUnchangedConfigurationOnce(t *testing.T) {
+	client := &clientMock{
+		applications: apps,
+		services:     services,
+		partitions:   partitions,
+		instances:    instances,
+		getServiceExtensionMapResult: map[string]string{
+			label.TraefikEnable: "true",
+		},
+	}
+
+	provider := Provider{
+		clusters: []*clusterConnection{{client: client}},
+	}
+	configurationChan := make(chan types.ConfigMessage, 10)
+	pool := safe.NewPool(context.Background())
+	defer pool.Stop()
+
+	err := provider.updateConfig(configurationChan, pool, 20*time.Millisecond)
+	require.NoError(t, err)
+
+	select {
+	case <-configurationChan:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Provider failed to return configuration")
+	}
+
+	select {
+	case <-configurationChan:
+		t.Fatal("Unchanged configuration sent twice")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
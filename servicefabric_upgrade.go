@@ -0,0 +1,59 @@
+package servicefabric
+
+import (
+	sf "github.com/jjcollinge/servicefabric"
+	"github.com/traefik/traefik/log"
+)
+
+const applicationStatusUpgrading = "Upgrading"
+
+// Upgrade states of an application upgrade taking upgrade domains down.
+const (
+	upgradeStateRollingForwardInProgress = "RollingForwardInProgress"
+	upgradeStateRollingForwardPending    = "RollingForwardPending"
+	upgradeStateRollingBackInProgress    = "RollingBackInProgress"
+)
+
+// getNodeFilter returns the node filter of the application, the upgrade
+// domains being upgraded are drained when DrainUpgradeDomains is set.
+// The upgrade progress is only queried for upgrading applications.
+func (p *Provider) getNodeFilter(sfClient sfClient, pool *workerPool, nodes map[string]NodeItem, app sf.ApplicationItem) nodeFilter {
+	filter := nodeFilter{nodes: nodes}
+	if !p.DrainUpgradeDomains || app.Status != applicationStatusUpgrading {
+		return filter
+	}
+
+	var progress *applicationUpgradeProgress
+	var err error
+	pool.run(func() {
+		progress, err = sfClient.GetUpgradeProgress(app.ID)
+	})
+	if err != nil {
+		log.Warnf("Unable to get the upgrade progress of application %s, upgrade domains are not drained: %v", app.Name, err)
+		return filter
+	}
+
+	filter.drainedUpgradeDomains = getDrainedUpgradeDomains(progress)
+	if len(filter.drainedUpgradeDomains) > 0 {
+		log.Debugf("Draining upgrade domains %v of application %s", filter.drainedUpgradeDomains, app.Name)
+	}
+	return filter
+}
+
+// getDrainedUpgradeDomains returns the upgrade domains whose replicas are
+// removed: the domain being upgraded or rolled back, and the next domain
+// of an upgrade waiting to move on, before it is taken down.
+func getDrainedUpgradeDomains(progress *applicationUpgradeProgress) map[string]struct{} {
+	var domain string
+	switch progress.UpgradeState {
+	case upgradeStateRollingForwardInProgress, upgradeStateRollingBackInProgress:
+		domain = progress.CurrentUpgradeDomainProgress.DomainName
+	case upgradeStateRollingForwardPending:
+		domain = progress.NextUpgradeDomain
+	}
+
+	if domain == "" {
+		return nil
+	}
+	return map[string]struct{}{domain: {}}
+}
@@ -0,0 +1,193 @@
+package servicefabric
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sf "github.com/jjcollinge/servicefabric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/provider/label"
+)
+
+func TestClusterClientGetUpgradeProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/Applications/TestApplication/$/GetUpgradeProgress" {
+			http.NotFound(rw, req)
+			return
+		}
+
+		fmt.Fprint(rw, `{
+			"Name": "fabric:/TestApplication",
+			"TypeName": "TestApplicationType",
+			"TargetApplicationTypeVersion": "2.0.0",
+			"UpgradeDomains": [{"Name": "0", "State": "Completed"}, {"Name": "1", "State": "InProgress"}, {"Name": "2", "State": "Pending"}],
+			"UpgradeState": "RollingForwardInProgress",
+			"NextUpgradeDomain": "2",
+			"RollingUpgradeMode": "Monitored",
+			"CurrentUpgradeDomainProgress": {"DomainName": "1", "NodeUpgradeProgressList": []}
+		}`)
+	}))
+	defer server.Close()
+
+	client, err := newClusterClient(&http.Client{}, server.URL, "")
+	require.NoError(t, err)
+
+	progress, err := client.GetUpgradeProgress("TestApplication")
+	require.NoError(t, err)
+
+	assert.Equal(t, "RollingForwardInProgress", progress.UpgradeState)
+	assert.Equal(t, "2", progress.NextUpgradeDomain)
+	assert.Equal(t, "1", progress.CurrentUpgradeDomainProgress.DomainName)
+}
+
+func TestGetDrainedUpgradeDomains(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		payload  string
+		expected map[string]struct{}
+	}{
+		{
+			desc:     "rolling forward",
+			payload:  `{"UpgradeState": "RollingForwardInProgress", "NextUpgradeDomain": "2", "CurrentUpgradeDomainProgress": {"DomainName": "1"}}`,
+			expected: map[string]struct{}{"1": {}},
+		},
+		{
+			desc:     "rolling back",
+			payload:  `{"UpgradeState": "RollingBackInProgress", "CurrentUpgradeDomainProgress": {"DomainName": "0"}}`,
+			expected: map[string]struct{}{"0": {}},
+		},
+		{
+			desc:     "waiting for the next upgrade domain",
+			payload:  `{"UpgradeState": "RollingForwardPending", "NextUpgradeDomain": "2", "CurrentUpgradeDomainProgress": {"DomainName": ""}}`,
+			expected: map[string]struct{}{"2": {}},
+		},
+		{
+			desc:    "upgrade completed",
+			payload: `{"UpgradeState": "RollingForwardCompleted", "NextUpgradeDomain": "", "CurrentUpgradeDomainProgress": {"DomainName": ""}}`,
+		},
+		{
+			desc:    "no current upgrade domain",
+			payload: `{"UpgradeState": "RollingForwardInProgress", "NextUpgradeDomain": "0", "CurrentUpgradeDomainProgress": {"DomainName": ""}}`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			progress := &applicationUpgradeProgress{}
+			require.NoError(t, json.Unmarshal([]byte(test.payload), progress))
+
+			assert.Equal(t, test.expected, getDrainedUpgradeDomains(progress))
+		})
+	}
+}
+
+func TestGetServicesDrainUpgradeDomains(t *testing.T) {
+	newInstance := func(id, nodeName string) sf.InstanceItem {
+		instance := newTestInstance(id, "http://localhost:808"+id)
+		instance.NodeName = nodeName
+		return instance
+	}
+
+	testCases := []struct {
+		desc                 string
+		drainUpgradeDomains  bool
+		appStatus            string
+		upgradeProgress      *applicationUpgradeProgress
+		upgradeProgressError error
+		expectedInstances    []string
+	}{
+		{
+			desc:                "drain the upgrade domain being upgraded",
+			drainUpgradeDomains: true,
+			appStatus:           applicationStatusUpgrading,
+			upgradeProgress:     newUpgradeProgress(upgradeStateRollingForwardInProgress, "1"),
+			expectedInstances:   []string{"1", "3"},
+		},
+		{
+			desc:              "drain disabled",
+			appStatus:         applicationStatusUpgrading,
+			upgradeProgress:   newUpgradeProgress(upgradeStateRollingForwardInProgress, "1"),
+			expectedInstances: []string{"1", "2", "3"},
+		},
+		{
+			desc:                "application not upgrading",
+			drainUpgradeDomains: true,
+			appStatus:           "Ready",
+			upgradeProgress:     newUpgradeProgress(upgradeStateRollingForwardInProgress, "1"),
+			expectedInstances:   []string{"1", "2", "3"},
+		},
+		{
+			desc:                 "upgrade progress unavailable",
+			drainUpgradeDomains:  true,
+			appStatus:            applicationStatusUpgrading,
+			upgradeProgressError: errors.New("upgrade progress unavailable"),
+			expectedInstances:    []string{"1", "2", "3"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			app := apps.Items[0]
+			app.Status = test.appStatus
+
+			client := &clientMock{
+				applications: &sf.ApplicationItemsPage{Items: []sf.ApplicationItem{app}},
+				services:     services,
+				partitions:   partitions,
+				instances: &sf.InstanceItemsPage{
+					Items: []sf.InstanceItem{
+						newInstance("1", "_Node_0"),
+						newInstance("2", "_Node_1"),
+						newInstance("3", "_Node_2"),
+					},
+				},
+				nodes: &nodeItemsPage{
+					Items: []NodeItem{
+						{Name: "_Node_0", NodeStatus: "Up", UpgradeDomain: "0"},
+						{Name: "_Node_1", NodeStatus: "Up", UpgradeDomain: "1"},
+						{Name: "_Node_2", NodeStatus: "Up", UpgradeDomain: "2"},
+					},
+				},
+				upgradeProgress: map[string]*applicationUpgradeProgress{
+					app.ID: test.upgradeProgress,
+				},
+				upgradeProgressError: test.upgradeProgressError,
+				getServiceExtensionMapResult: map[string]string{
+					label.TraefikEnable: "true",
+				},
+			}
+
+			provider := Provider{
+				DrainUpgradeDomains: test.drainUpgradeDomains,
+				clusters:            []*clusterConnection{{client: client}},
+			}
+
+			serviceItems, err := provider.getServices()
+			require.NoError(t, err)
+			require.Len(t, serviceItems, 1)
+
+			var instanceIDs []string
+			for _, instance := range serviceItems[0].Partitions[0].Instances {
+				instanceIDs = append(instanceIDs, instance.ID)
+			}
+			assert.Equal(t, test.expectedInstances, instanceIDs)
+		})
+	}
+}
+
+func newUpgradeProgress(state, domainName string) *applicationUpgradeProgress {
+	progress := &applicationUpgradeProgress{UpgradeState: state}
+	progress.CurrentUpgradeDomainProgress.DomainName = domainName
+	return progress
+}
@@ -1,38 +1,121 @@
 package servicefabric
 
 import (
+	"encoding/json"
+	"time"
+
 	sf "github.com/jjcollinge/servicefabric"
 )
 
 // ServiceItemExtended provides a flattened view
 // of the service with details of the application
 // it belongs too and the replicas/partitions.
+// Stale is set when the service comes from the last known
+// state of an application whose discovery failed.
+// Cluster is the name of the cluster the service belongs to,
+// empty for the unnamed default cluster.
+// Segment is the name of the label segment the service is exposed
+// with, empty for the default segment.
 type ServiceItemExtended struct {
 	sf.ServiceItem
+	Cluster     string
+	Segment     string
 	Application sf.ApplicationItem
 	Partitions  []PartitionItemExtended
 	Labels      map[string]string
+	Stale       bool
 }
 
 // PartitionItemExtended provides a flattened view
 // of a services partitions.
+// Name is the name of a Named partition.
+// Nodes are the nodes hosting the replicas and instances, keyed by node name,
+// they carry the fault and upgrade domains of the replicas: sf.ReplicaItem
+// is an upstream type which has no room for them.
 type PartitionItemExtended struct {
 	sf.PartitionItem
+	Name      string
 	Replicas  []sf.ReplicaItem
 	Instances []sf.InstanceItem
+	Nodes     map[string]NodeItem
+}
+
+// NodeItem is the subset of a Service Fabric node used by the provider.
+type NodeItem struct {
+	Name          string `json:"Name"`
+	NodeStatus    string `json:"NodeStatus"`
+	UpgradeDomain string `json:"UpgradeDomain"`
+	FaultDomain   string `json:"FaultDomain"`
+}
+
+type nodeItemsPage struct {
+	ContinuationToken *string    `json:"ContinuationToken"`
+	Items             []NodeItem `json:"Items"`
+}
+
+// applicationUpgradeProgress is the subset of the upgrade progress
+// of an application used by the provider.
+type applicationUpgradeProgress struct {
+	UpgradeState                 string `json:"UpgradeState"`
+	NextUpgradeDomain            string `json:"NextUpgradeDomain"`
+	CurrentUpgradeDomainProgress struct {
+		DomainName string `json:"DomainName"`
+	} `json:"CurrentUpgradeDomainProgress"`
+}
+
+// partitionItemsPage is a page of partitions which also decodes the names
+// of the Named partitions, sf.PartitionInformation has no Name field.
+// Names are keyed by partition ID.
+type partitionItemsPage struct {
+	sf.PartitionItemsPage
+	Names map[string]string
+}
+
+func (p *partitionItemsPage) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &p.PartitionItemsPage); err != nil {
+		return err
+	}
+
+	var page struct {
+		Items []struct {
+			PartitionInformation struct {
+				ID   string `json:"Id"`
+				Name string `json:"Name"`
+			} `json:"PartitionInformation"`
+		} `json:"Items"`
+	}
+	if err := json.Unmarshal(data, &page); err != nil {
+		return err
+	}
+
+	for _, item := range page.Items {
+		if item.PartitionInformation.Name == "" {
+			continue
+		}
+		if p.Names == nil {
+			p.Names = make(map[string]string)
+		}
+		p.Names[item.PartitionInformation.ID] = item.PartitionInformation.Name
+	}
+	return nil
 }
 
 // sfClient is an interface for Service Fabric client's to implement.
 // This is purposely a subset of the total Service Fabric API surface.
+// The list methods return a single page, an empty continuation token
+// requests the first one.
 type sfClient interface {
-	GetApplications() (*sf.ApplicationItemsPage, error)
-	GetServices(appName string) (*sf.ServiceItemsPage, error)
-	GetPartitions(appName, serviceName string) (*sf.PartitionItemsPage, error)
-	GetReplicas(appName, serviceName, partitionName string) (*sf.ReplicaItemsPage, error)
-	GetInstances(appName, serviceName, partitionName string) (*sf.InstanceItemsPage, error)
+	GetApplications(continuationToken string) (*sf.ApplicationItemsPage, error)
+	GetServices(appName, continuationToken string) (*sf.ServiceItemsPage, error)
+	GetPartitions(appName, serviceName, continuationToken string) (*partitionItemsPage, error)
+	GetReplicas(appName, serviceName, partitionName, continuationToken string) (*sf.ReplicaItemsPage, error)
+	GetInstances(appName, serviceName, partitionName, continuationToken string) (*sf.InstanceItemsPage, error)
 	GetServiceExtensionMap(service *sf.ServiceItem, app *sf.ApplicationItem, extensionKey string) (map[string]string, error)
 	GetServiceLabels(service *sf.ServiceItem, app *sf.ApplicationItem, prefix string) (map[string]string, error)
 	GetProperties(name string) (bool, map[string]string, error)
+	GetEvents(entity string, start, end time.Time) ([]fabricEvent, error)
+	GetNodes(continuationToken string) (*nodeItemsPage, error)
+	GetUpgradeProgress(appName string) (*applicationUpgradeProgress, error)
 }
 
 // replicaInstance interface provides a unified interface